@@ -0,0 +1,373 @@
+// Package bitmap provides a compressed bitmap of unsigned integers.
+//
+// Like a roaring bitmap, values are partitioned by their high bits into containers of 65536
+// values. A container with few values stores them in a sorted array, and a container with
+// many values stores them in a bitset.
+package bitmap
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// maxArrayLen is the maximum number of values stored in an array container
+const maxArrayLen = 4096
+
+// bitsetWords is the number of words of a bitset container
+const bitsetWords = 1 << 16 / 64
+
+// container contains the low 16 bits of the values sharing the same high bits
+type container struct {
+	Array  []uint16 // Sorted values. Used if Bitset is nil
+	Bitset []uint64 // Bitset of values
+	Len    int      // Number of values
+}
+
+// Bitmap represents a set of uint64 values. The zero value is an empty bitmap.
+type Bitmap struct {
+	keys       []uint64 // Sorted high bits of the values
+	containers []*container
+}
+
+// New returns a bitmap containing values
+func New(values ...uint64) *Bitmap {
+	b := &Bitmap{}
+	for _, v := range values {
+		b.Add(v)
+	}
+	return b
+}
+
+// split returns the high and low bits of value v
+func split(v uint64) (uint64, uint16) {
+	return v >> 16, uint16(v)
+}
+
+// Add adds value v to the bitmap
+func (b *Bitmap) Add(v uint64) {
+	key, low := split(v)
+	i, found := slices.BinarySearch(b.keys, key)
+	if !found {
+		b.keys = slices.Insert(b.keys, i, key)
+		b.containers = slices.Insert(b.containers, i, &container{})
+	}
+	b.containers[i].add(low)
+}
+
+// Remove removes value v from the bitmap
+func (b *Bitmap) Remove(v uint64) {
+	key, low := split(v)
+	i, found := slices.BinarySearch(b.keys, key)
+	if !found {
+		return
+	}
+	b.containers[i].remove(low)
+	if b.containers[i].Len == 0 {
+		b.keys = slices.Delete(b.keys, i, i+1)
+		b.containers = slices.Delete(b.containers, i, i+1)
+	}
+}
+
+// Contains returns true if value v is in the bitmap
+func (b *Bitmap) Contains(v uint64) bool {
+	key, low := split(v)
+	i, found := slices.BinarySearch(b.keys, key)
+	return found && b.containers[i].contains(low)
+}
+
+// Len returns the number of values in the bitmap
+func (b *Bitmap) Len() int {
+	n := 0
+	for _, c := range b.containers {
+		n += c.Len
+	}
+	return n
+}
+
+// Clone returns a copy of the bitmap
+func (b *Bitmap) Clone() *Bitmap {
+	result := &Bitmap{keys: slices.Clone(b.keys), containers: make([]*container, len(b.containers))}
+	for i, c := range b.containers {
+		result.containers[i] = &container{Array: slices.Clone(c.Array), Bitset: slices.Clone(c.Bitset), Len: c.Len}
+	}
+	return result
+}
+
+// Union returns a new bitmap containing the values in either b or a
+func (b *Bitmap) Union(a *Bitmap) *Bitmap {
+	result := &Bitmap{}
+	i, j := 0, 0
+	for i < len(b.keys) || j < len(a.keys) {
+		switch {
+		case j == len(a.keys) || (i < len(b.keys) && b.keys[i] < a.keys[j]):
+			result.append(b.keys[i], b.containers[i].clone())
+			i++
+		case i == len(b.keys) || a.keys[j] < b.keys[i]:
+			result.append(a.keys[j], a.containers[j].clone())
+			j++
+		default:
+			result.append(b.keys[i], b.containers[i].union(a.containers[j]))
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// Intersect returns a new bitmap containing the values in both b and a
+func (b *Bitmap) Intersect(a *Bitmap) *Bitmap {
+	result := &Bitmap{}
+	i, j := 0, 0
+	for i < len(b.keys) && j < len(a.keys) {
+		switch {
+		case b.keys[i] < a.keys[j]:
+			i++
+		case a.keys[j] < b.keys[i]:
+			j++
+		default:
+			if c := b.containers[i].filter(a.containers[j], true); c.Len > 0 {
+				result.append(b.keys[i], c)
+			}
+			i++
+			j++
+		}
+	}
+	return result
+}
+
+// Difference returns a new bitmap containing the values in b but not in a
+func (b *Bitmap) Difference(a *Bitmap) *Bitmap {
+	result := &Bitmap{}
+	j := 0
+	for i, key := range b.keys {
+		for j < len(a.keys) && a.keys[j] < key {
+			j++
+		}
+		c := b.containers[i].clone()
+		if j < len(a.keys) && a.keys[j] == key {
+			c = b.containers[i].filter(a.containers[j], false)
+		}
+		if c.Len > 0 {
+			result.append(key, c)
+		}
+	}
+	return result
+}
+
+// append adds a container with the largest key to the bitmap
+func (b *Bitmap) append(key uint64, c *container) {
+	b.keys = append(b.keys, key)
+	b.containers = append(b.containers, c)
+}
+
+// ForEach calls fn for each value of the bitmap in ascending order.
+// If fn returns false, ForEach stops the iteration and returns false.
+func (b *Bitmap) ForEach(fn func(v uint64) bool) bool {
+	for i, c := range b.containers {
+		high := b.keys[i] << 16
+		if !c.forEach(func(low uint16) bool { return fn(high | uint64(low)) }) {
+			return false
+		}
+	}
+	return true
+}
+
+// Ranges calls fn for each run of consecutive values of the bitmap in ascending order.
+// Both lo and hi are in the bitmap. If fn returns false, Ranges stops the iteration and returns false.
+//
+// For example:
+//
+//	Ranges of `1,2,3,5` calls fn(1, 3) and fn(5, 5)
+func (b *Bitmap) Ranges(fn func(lo, hi uint64) bool) bool {
+	started := false
+	var lo, hi uint64
+	ok := b.ForEach(func(v uint64) bool {
+		if started && v == hi+1 {
+			hi = v
+			return true
+		}
+		if started && !fn(lo, hi) {
+			return false
+		}
+		started, lo, hi = true, v, v
+		return true
+	})
+	if !ok {
+		return false
+	}
+	if started {
+		return fn(lo, hi)
+	}
+	return true
+}
+
+func (c *container) add(v uint16) {
+	if c.Bitset != nil {
+		if c.Bitset[v/64]&(1<<(v%64)) == 0 {
+			c.Bitset[v/64] |= 1 << (v % 64)
+			c.Len++
+		}
+		return
+	}
+
+	i, found := slices.BinarySearch(c.Array, v)
+	if found {
+		return
+	}
+	c.Array = slices.Insert(c.Array, i, v)
+	c.Len++
+	if c.Len > maxArrayLen {
+		c.toBitset()
+	}
+}
+
+func (c *container) remove(v uint16) {
+	if c.Bitset != nil {
+		if c.Bitset[v/64]&(1<<(v%64)) != 0 {
+			c.Bitset[v/64] &^= 1 << (v % 64)
+			c.Len--
+		}
+		if c.Len <= maxArrayLen {
+			c.toArray()
+		}
+		return
+	}
+
+	if i, found := slices.BinarySearch(c.Array, v); found {
+		c.Array = slices.Delete(c.Array, i, i+1)
+		c.Len--
+	}
+}
+
+func (c *container) contains(v uint16) bool {
+	if c.Bitset != nil {
+		return c.Bitset[v/64]&(1<<(v%64)) != 0
+	}
+	_, found := slices.BinarySearch(c.Array, v)
+	return found
+}
+
+// toBitset converts an array container to a bitset container
+func (c *container) toBitset() {
+	c.Bitset = make([]uint64, bitsetWords)
+	for _, v := range c.Array {
+		c.Bitset[v/64] |= 1 << (v % 64)
+	}
+	c.Array = nil
+}
+
+// toArray converts a bitset container to an array container
+func (c *container) toArray() {
+	array := make([]uint16, 0, c.Len)
+	c.forEach(func(v uint16) bool {
+		array = append(array, v)
+		return true
+	})
+	c.Array, c.Bitset = array, nil
+}
+
+func (c *container) clone() *container {
+	return &container{Array: slices.Clone(c.Array), Bitset: slices.Clone(c.Bitset), Len: c.Len}
+}
+
+func (c *container) forEach(fn func(v uint16) bool) bool {
+	if c.Bitset == nil {
+		for _, v := range c.Array {
+			if !fn(v) {
+				return false
+			}
+		}
+		return true
+	}
+
+	for i, word := range c.Bitset {
+		for word != 0 {
+			t := bits.TrailingZeros64(word)
+			if !fn(uint16(i*64 + t)) {
+				return false
+			}
+			word &= word - 1
+		}
+	}
+	return true
+}
+
+// union returns a new container containing the values in either c or a
+func (c *container) union(a *container) *container {
+	if c.Bitset == nil && a.Bitset == nil && c.Len+a.Len <= maxArrayLen {
+		// Merge sorted arrays
+		result := &container{Array: make([]uint16, 0, c.Len+a.Len)}
+		i, j := 0, 0
+		for i < len(c.Array) && j < len(a.Array) {
+			switch {
+			case c.Array[i] < a.Array[j]:
+				result.Array = append(result.Array, c.Array[i])
+				i++
+			case a.Array[j] < c.Array[i]:
+				result.Array = append(result.Array, a.Array[j])
+				j++
+			default:
+				result.Array = append(result.Array, c.Array[i])
+				i++
+				j++
+			}
+		}
+		result.Array = append(result.Array, c.Array[i:]...)
+		result.Array = append(result.Array, a.Array[j:]...)
+		result.Len = len(result.Array)
+		return result
+	}
+
+	result := c.clone()
+	if result.Bitset == nil {
+		result.toBitset()
+	}
+	if a.Bitset != nil {
+		result.Len = 0
+		for i := range result.Bitset {
+			result.Bitset[i] |= a.Bitset[i]
+			result.Len += bits.OnesCount64(result.Bitset[i])
+		}
+	} else {
+		for _, v := range a.Array {
+			result.add(v)
+		}
+	}
+	if result.Len <= maxArrayLen {
+		result.toArray()
+	}
+	return result
+}
+
+// filter returns a new container containing the values of c which are in a if keep is true,
+// or which are not in a if keep is false
+func (c *container) filter(a *container, keep bool) *container {
+	if c.Bitset != nil && a.Bitset != nil {
+		result := &container{Bitset: make([]uint64, bitsetWords)}
+		for i := range result.Bitset {
+			if keep {
+				result.Bitset[i] = c.Bitset[i] & a.Bitset[i]
+			} else {
+				result.Bitset[i] = c.Bitset[i] &^ a.Bitset[i]
+			}
+			result.Len += bits.OnesCount64(result.Bitset[i])
+		}
+		if result.Len <= maxArrayLen {
+			result.toArray()
+		}
+		return result
+	}
+
+	result := &container{Array: []uint16{}}
+	c.forEach(func(v uint16) bool {
+		if a.contains(v) == keep {
+			result.Array = append(result.Array, v)
+		}
+		return true
+	})
+	result.Len = len(result.Array)
+	if result.Len > maxArrayLen {
+		result.toBitset()
+	}
+	return result
+}
@@ -0,0 +1,120 @@
+package bitmap_test
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/puttsk/hostlist/bitmap"
+)
+
+// values returns the values of a bitmap in ascending order
+func values(b *bitmap.Bitmap) []uint64 {
+	result := []uint64{}
+	b.ForEach(func(v uint64) bool {
+		result = append(result, v)
+		return true
+	})
+	return result
+}
+
+// setValues returns the values of a set in ascending order
+func setValues(set map[uint64]bool) []uint64 {
+	result := []uint64{}
+	for v := range set {
+		result = append(result, v)
+	}
+	slices.Sort(result)
+	return result
+}
+
+// randomSet returns n random values less than limit. Large sets use bitset containers.
+func randomSet(r *rand.Rand, n int, limit uint64) map[uint64]bool {
+	set := map[uint64]bool{}
+	for i := 0; i < n; i++ {
+		set[r.Uint64()%limit] = true
+	}
+	return set
+}
+
+var BitmapTestcases = []struct {
+	N     int
+	Limit uint64
+}{
+	{N: 0, Limit: 10},
+	{N: 10, Limit: 20},
+	{N: 1000, Limit: 1 << 20},
+	{N: 10000, Limit: 1 << 16},
+	{N: 30000, Limit: 1 << 18},
+	{N: 100, Limit: 1 << 63},
+}
+
+// TestBitmap compares the results of bitmap operations with sets of values
+func TestBitmap(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, c := range BitmapTestcases {
+		t.Logf("Testcase: %d values less than %d\n", c.N, c.Limit)
+		setA, setB := randomSet(r, c.N, c.Limit), randomSet(r, c.N, c.Limit)
+		a, b := bitmap.New(setValues(setA)...), bitmap.New(setValues(setB)...)
+
+		if !reflect.DeepEqual(values(a), setValues(setA)) {
+			t.Fatalf("Invalid values: actual: %v expect: %v", values(a), setValues(setA))
+		}
+		if a.Len() != len(setA) {
+			t.Fatalf("Invalid length: actual: %d expect: %d", a.Len(), len(setA))
+		}
+
+		union, intersection, difference := map[uint64]bool{}, map[uint64]bool{}, map[uint64]bool{}
+		for v := range setA {
+			union[v] = true
+			if setB[v] {
+				intersection[v] = true
+			} else {
+				difference[v] = true
+			}
+		}
+		for v := range setB {
+			union[v] = true
+		}
+
+		if result := values(a.Union(b)); !reflect.DeepEqual(result, setValues(union)) {
+			t.Fatalf("Invalid union: actual: %v expect: %v", result, setValues(union))
+		}
+		if result := values(a.Intersect(b)); !reflect.DeepEqual(result, setValues(intersection)) {
+			t.Fatalf("Invalid intersection: actual: %v expect: %v", result, setValues(intersection))
+		}
+		if result := values(a.Difference(b)); !reflect.DeepEqual(result, setValues(difference)) {
+			t.Fatalf("Invalid difference: actual: %v expect: %v", result, setValues(difference))
+		}
+
+		// Remove values until the bitmap is empty
+		for _, v := range setValues(setA) {
+			if !a.Contains(v) {
+				t.Fatalf("Value not found: %d", v)
+			}
+			a.Remove(v)
+			if a.Contains(v) {
+				t.Fatalf("Value not removed: %d", v)
+			}
+		}
+		if a.Len() != 0 {
+			t.Fatalf("Invalid length: actual: %d expect: %d", a.Len(), 0)
+		}
+	}
+}
+
+// TestBitmapRanges tests Bitmap.Ranges
+func TestBitmapRanges(t *testing.T) {
+	b := bitmap.New(1, 2, 3, 5, 65535, 65536, 65537, 1<<40)
+	ranges := [][2]uint64{}
+	b.Ranges(func(lo, hi uint64) bool {
+		ranges = append(ranges, [2]uint64{lo, hi})
+		return true
+	})
+
+	expected := [][2]uint64{{1, 3}, {5, 5}, {65535, 65537}, {1 << 40, 1 << 40}}
+	if !reflect.DeepEqual(ranges, expected) {
+		t.Fatalf("Invalid ranges: actual: %v expect: %v", ranges, expected)
+	}
+}
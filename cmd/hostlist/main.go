@@ -1,42 +1,303 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 
 	"github.com/puttsk/hostlist"
+	"github.com/puttsk/hostlist/compress"
+	"github.com/puttsk/hostlist/expand"
 )
 
+// Exit codes
+const (
+	exitParse = 1 // Invalid hostlist expression or hostname
+	exitUsage = 2 // Invalid flags or arguments
+	exitLimit = 3 // Too many hostnames
+	exitInput = 4 // Input cannot be read
+)
+
+var errLimitExceeded = errors.New("number of hostnames exceeds the limit")
+var errNoHostnames = errors.New("no hostnames")
+
+// command represents an invocation of the command line interface
+type command struct {
+	Name   string // Name of the program
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+	Quiet  bool // Do not print error messages
+}
+
 func main() {
-	var expand bool
-	flag.BoolVar(&expand, "expand", false, "Expand hostlist expression")
-	flag.BoolVar(&expand, "e", false, "See. -expand")
+	cmd := &command{Name: os.Args[0], Stdin: os.Stdin, Stdout: os.Stdout, Stderr: os.Stderr}
+	os.Exit(cmd.run(os.Args[1:]))
+}
+
+// fail prints err to stderr, unless quiet mode is enabled, and returns code
+func (c *command) fail(err error, code int) int {
+	if !c.Quiet {
+		fmt.Fprintln(c.Stderr, "Error: "+err.Error())
+	}
+	return code
+}
+
+// parseFlags parses args with flags. Parse errors and the usage are printed to stderr, unless quiet
+// mode is enabled, while help requested with -h is always printed. It returns false and the exit
+// code if the command must exit.
+func (c *command) parseFlags(flags *flag.FlagSet, args []string) (int, bool) {
+	output := &strings.Builder{}
+	flags.SetOutput(output)
+
+	err := flags.Parse(args)
+	if err == nil {
+		return 0, true
+	}
+	if err == flag.ErrHelp {
+		fmt.Fprint(c.Stderr, output.String())
+		return 0, false
+	}
+	if !c.Quiet {
+		fmt.Fprint(c.Stderr, output.String())
+	}
+	return exitUsage, false
+}
+
+// exitCode returns the exit code of an error from expanding or compressing hostnames
+func exitCode(err error) int {
+	if errors.Is(err, errLimitExceeded) || errors.Is(err, expand.ErrTooManyHosts) {
+		return exitLimit
+	}
+	return exitParse
+}
+
+// run runs the command with the arguments, excluding the program name, and returns the exit code
+func (c *command) run(args []string) int {
+	// Subcommands
+	if len(args) > 0 && args[0] == "tree" {
+		return c.tree(args[1:])
+	}
 
-	var compress bool
-	flag.BoolVar(&compress, "compress", false, "Compress list of hostnames to hostlist expression")
-	flag.BoolVar(&compress, "c", false, "See. -compress")
+	flags := flag.NewFlagSet(c.Name, flag.ContinueOnError)
 
-	flag.Parse()
+	var expandMode bool
+	flags.BoolVar(&expandMode, "expand", false, "Expand hostlist expression")
+	flags.BoolVar(&expandMode, "e", false, "See. -expand")
 
-	if expand && compress {
-		fmt.Println("Must choose either expand (-e) or compress (-c) mode.")
-		os.Exit(1)
+	var compressMode bool
+	flags.BoolVar(&compressMode, "compress", false, "Compress list of hostnames to hostlist expression")
+	flags.BoolVar(&compressMode, "c", false, "See. -compress")
+
+	var report bool
+	flags.BoolVar(&report, "report", false, "Print diagnostics about the compressed expression to stderr. For compress mode only")
+
+	var file string
+	flags.StringVar(&file, "f", "", "Read hostlist expressions or hostnames from `file`")
+
+	var limit int
+	flags.IntVar(&limit, "limit", 0, "Maximum number of expanded hostnames. 0 for no limit. For expand mode only")
+
+	flags.BoolVar(&c.Quiet, "q", false, "Quiet mode. Do not print error messages")
+
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage of %s: [flags] [expression or hostname ...]\n", c.Name)
+		fmt.Fprintf(flags.Output(), "Arguments are read from stdin if there is no argument or an argument is '-'.\n")
+		fmt.Fprintf(flags.Output(), "Input can be separated by newlines, spaces, or commas.\n\n")
+		flags.PrintDefaults()
+		fmt.Fprintf(flags.Output(), "\nSubcommands:\n  tree\tPrint the expression tree of list of hostnames. See. %s tree -h\n", c.Name)
+		fmt.Fprintf(flags.Output(), "\nExit codes:\n  0\tSuccess\n  %d\tInvalid expression or hostname\n  %d\tInvalid usage\n  %d\tToo many hostnames\n  %d\tInput cannot be read\n",
+			exitParse, exitUsage, exitLimit, exitInput)
+	}
+
+	if code, ok := c.parseFlags(flags, args); !ok {
+		return code
+	}
+
+	if expandMode && compressMode {
+		return c.fail(errors.New("must choose either expand (-e) or compress (-c) mode"), exitUsage)
+	}
+	if limit < 0 {
+		return c.fail(errors.New("limit must not be negative"), exitUsage)
 	}
 	// Choose either expand or compress mode
-	expand = !compress
+	expandMode = !compressMode
 
-	if expand {
-		hosts, err := hostlist.Expand(flag.Arg(0))
+	inputs, err := readInputs(flags.Args(), file, c.Stdin)
+	if err != nil {
+		return c.fail(err, exitInput)
+	}
+	expressions := splitExpressions(inputs)
+
+	if expandMode {
+		if len(expressions) == 0 {
+			return c.fail(expand.ErrEmptyExpression, exitParse)
+		}
+		hosts, err := expandInputs(expressions, limit)
+		if err != nil {
+			return c.fail(err, exitCode(err))
+		}
+		fmt.Fprintf(c.Stdout, "%s\n", strings.Join(hosts, " "))
+		return 0
+	}
+
+	if len(expressions) == 0 {
+		return c.fail(errNoHostnames, exitParse)
+	}
+	if report {
+		expr, r, err := hostlist.CompressWithReport(expressions, compress.Options{})
 		if err != nil {
-			fmt.Print("Error: " + err.Error())
+			return c.fail(err, exitCode(err))
 		}
-		fmt.Printf("%s\n", strings.Join(hosts, " "))
-	} else if compress {
-		hosts := flag.Args()
-		expr, _ := hostlist.Compress(hosts)
+		fmt.Fprintln(c.Stdout, expr)
+		fmt.Fprint(c.Stderr, r.String())
+		return 0
+	}
+	expr, err := hostlist.Compress(expressions)
+	if err != nil {
+		return c.fail(err, exitCode(err))
+	}
 
-		fmt.Println(expr)
+	fmt.Fprintln(c.Stdout, expr)
+	return 0
+}
+
+// tree prints the structure of the expression tree built from list of hostnames
+func (c *command) tree(args []string) int {
+	flags := flag.NewFlagSet("tree", flag.ContinueOnError)
+
+	var format string
+	flags.StringVar(&format, "format", "text", "Output format: text, json, or dot")
+
+	var file string
+	flags.StringVar(&file, "f", "", "Read hostnames from `file`")
+
+	flags.BoolVar(&c.Quiet, "q", false, "Quiet mode. Do not print error messages")
+
+	if code, ok := c.parseFlags(flags, args); !ok {
+		return code
+	}
+
+	inputs, err := readInputs(flags.Args(), file, c.Stdin)
+	if err != nil {
+		return c.fail(err, exitInput)
+	}
+	hosts := splitExpressions(inputs)
+	if len(hosts) == 0 {
+		return c.fail(errNoHostnames, exitParse)
+	}
+
+	exprTree := compress.NewHostlistExpressionTree()
+	for _, h := range hosts {
+		exprTree.AddHost(h)
+	}
+
+	switch format {
+	case "text":
+		fmt.Fprintln(c.Stdout, strings.TrimSpace(exprTree.String()))
+	case "json":
+		out, err := json.MarshalIndent(exprTree, "", "  ")
+		if err != nil {
+			return c.fail(err, exitParse)
+		}
+		fmt.Fprintln(c.Stdout, string(out))
+	case "dot":
+		fmt.Fprint(c.Stdout, exprTree.DOT())
+	default:
+		return c.fail(fmt.Errorf("unknown format: %s", format), exitUsage)
+	}
+	return 0
+}
+
+// expandInputs expands hostlist expressions. If limit is positive, expandInputs returns
+// errLimitExceeded as soon as the number of hostnames exceeds limit, without expanding the rest.
+func expandInputs(inputs []string, limit int) ([]string, error) {
+	hosts := []string{}
+	for _, expr := range inputs {
+		if limit == 0 {
+			var err error
+			if hosts, err = hostlist.AppendExpand(hosts, expr); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		exceeded := false
+		err := hostlist.ExpandFunc(expr, func(host []byte) bool {
+			if len(hosts) == limit {
+				exceeded = true
+				return false
+			}
+			hosts = append(hosts, string(host))
+			return true
+		})
+		if err != nil {
+			return nil, err
+		}
+		if exceeded {
+			return nil, fmt.Errorf("%w: %d", errLimitExceeded, limit)
+		}
+	}
+	return hosts, nil
+}
+
+// readInputs returns the hostlist expressions or hostnames from the arguments, the file, and stdin.
+// stdin is read if an argument is `-`, or if there is neither argument nor file. The content of
+// the file and stdin is split by whitespace, e.g., newlines and spaces.
+func readInputs(args []string, file string, stdin io.Reader) ([]string, error) {
+	inputs := []string{}
+	if file != "" {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, strings.Fields(string(content))...)
+	}
+
+	if len(args) == 0 && file == "" {
+		args = []string{"-"}
+	}
+	for _, arg := range args {
+		if arg != "-" {
+			inputs = append(inputs, strings.Fields(arg)...)
+			continue
+		}
+		content, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		inputs = append(inputs, strings.Fields(string(content))...)
+	}
+	return inputs, nil
+}
+
+// splitExpressions splits comma-separated hostlist expressions or hostnames. Commas inside
+// range expressions are kept, e.g., `node[1,2],login` is split into `node[1,2]` and `login`.
+// Empty expressions, e.g., left by leading or trailing commas, are removed.
+func splitExpressions(inputs []string) []string {
+	expressions := []string{}
+	for _, input := range inputs {
+		bracket, start := 0, 0
+		for i := 0; i < len(input); i++ {
+			switch {
+			case input[i] == '[':
+				bracket++
+			case input[i] == ']':
+				bracket--
+			case input[i] == ',' && bracket == 0:
+				if start < i {
+					expressions = append(expressions, input[start:i])
+				}
+				start = i + 1
+			}
+		}
+		if start < len(input) {
+			expressions = append(expressions, input[start:])
+		}
 	}
+	return expressions
 }
@@ -0,0 +1,301 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/puttsk/hostlist/expand"
+)
+
+type ReadInputsTestcase struct {
+	Args           []string
+	File           string // Content of the file. No file is read if empty
+	Stdin          string
+	ExpectedResult []string
+}
+
+var ReadInputsTestcases = []ReadInputsTestcase{
+	{
+		Args:           []string{"node[1-2]", "login"},
+		Stdin:          "ignored",
+		ExpectedResult: []string{"node[1-2]", "login"},
+	},
+	{
+		Args:           []string{},
+		Stdin:          "node1\nnode2 node3\n\n",
+		ExpectedResult: []string{"node1", "node2", "node3"},
+	},
+	{
+		Args:           []string{"login", "-"},
+		Stdin:          "node[1-2], node3",
+		ExpectedResult: []string{"login", "node[1-2],", "node3"},
+	},
+	{
+		Args:           []string{},
+		File:           "node1\r\nnode2\tnode3",
+		Stdin:          "ignored",
+		ExpectedResult: []string{"node1", "node2", "node3"},
+	},
+	{
+		Args:           []string{"-"},
+		File:           "node1",
+		Stdin:          "node2",
+		ExpectedResult: []string{"node1", "node2"},
+	},
+	{
+		Args:           []string{},
+		Stdin:          "",
+		ExpectedResult: []string{},
+	},
+}
+
+// TestReadInputs tests reading inputs from arguments, files, and stdin
+func TestReadInputs(t *testing.T) {
+	for _, c := range ReadInputsTestcases {
+		t.Logf("Testcase: args: %v file: %q stdin: %q\n", c.Args, c.File, c.Stdin)
+
+		file := ""
+		if c.File != "" {
+			file = filepath.Join(t.TempDir(), "hostfile")
+			if err := os.WriteFile(file, []byte(c.File), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		result, err := readInputs(c.Args, file, strings.NewReader(c.Stdin))
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if !reflect.DeepEqual(result, c.ExpectedResult) {
+			t.Fatalf("Invalid inputs: actual: %q expect: %q", result, c.ExpectedResult)
+		}
+	}
+
+	if _, err := readInputs(nil, filepath.Join(t.TempDir(), "missing"), strings.NewReader("")); !errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("Invalid error: actual: %v expected: %s", err, os.ErrNotExist)
+	}
+}
+
+type SplitExpressionsTestcase struct {
+	Inputs         []string
+	ExpectedResult []string
+}
+
+var SplitExpressionsTestcases = []SplitExpressionsTestcase{
+	{
+		Inputs:         []string{"node[1-2],", "node3"},
+		ExpectedResult: []string{"node[1-2]", "node3"},
+	},
+	{
+		Inputs:         []string{"node[1,3-4],login[a,b]"},
+		ExpectedResult: []string{"node[1,3-4]", "login[a,b]"},
+	},
+	{
+		Inputs:         []string{",node1,,node2,", ",", ""},
+		ExpectedResult: []string{"node1", "node2"},
+	},
+	{
+		Inputs:         []string{},
+		ExpectedResult: []string{},
+	},
+}
+
+// TestSplitExpressions tests splitting comma-separated expressions and hostnames
+func TestSplitExpressions(t *testing.T) {
+	for _, c := range SplitExpressionsTestcases {
+		t.Logf("Testcase: %q\n", c.Inputs)
+		result := splitExpressions(c.Inputs)
+		if !reflect.DeepEqual(result, c.ExpectedResult) {
+			t.Fatalf("Invalid expressions: actual: %q expect: %q", result, c.ExpectedResult)
+		}
+	}
+}
+
+type ExpandInputsTestcase struct {
+	Inputs         []string
+	Limit          int
+	ExpectedResult []string
+	ExpectedError  error
+}
+
+var ExpandInputsTestcases = []ExpandInputsTestcase{
+	{
+		Inputs:         []string{"node[1-2]", "node3"},
+		ExpectedResult: []string{"node1", "node2", "node3"},
+	},
+	{
+		Inputs:         []string{"node[1-2]", "node3"},
+		Limit:          3,
+		ExpectedResult: []string{"node1", "node2", "node3"},
+	},
+	{
+		Inputs:        []string{"node[1-2]", "node3"},
+		Limit:         2,
+		ExpectedError: errLimitExceeded,
+	},
+	{
+		Inputs:        []string{"node[1-99999999999999999999999]"},
+		Limit:         10,
+		ExpectedError: expand.ErrTooManyHosts,
+	},
+	{
+		Inputs:        []string{"node[1-2"},
+		ExpectedError: expand.ErrExpectedCloseBracket,
+	},
+}
+
+// TestExpandInputs tests expanding expressions with and without a limit
+func TestExpandInputs(t *testing.T) {
+	for _, c := range ExpandInputsTestcases {
+		t.Logf("Testcase: %q limit: %d\n", c.Inputs, c.Limit)
+		result, err := expandInputs(c.Inputs, c.Limit)
+		if !errors.Is(err, c.ExpectedError) || (c.ExpectedError == nil && err != nil) {
+			t.Fatalf("Invalid error: actual: %v expected: %v", err, c.ExpectedError)
+		}
+		if c.ExpectedError != nil {
+			continue
+		}
+		if !reflect.DeepEqual(result, c.ExpectedResult) {
+			t.Fatalf("Invalid hostnames: actual: %q expect: %q", result, c.ExpectedResult)
+		}
+	}
+}
+
+type RunTestcase struct {
+	Args           []string
+	Stdin          string
+	ExpectedCode   int
+	ExpectedStdout string
+	ExpectedError  bool // True if an error message is expected on stderr
+}
+
+var RunTestcases = []RunTestcase{
+	{
+		Args:           []string{"-e", "node[1-3]"},
+		ExpectedCode:   0,
+		ExpectedStdout: "node1 node2 node3\n",
+	},
+	{
+		Args:           []string{"-c"},
+		Stdin:          "node1,node2\nnode3\n",
+		ExpectedCode:   0,
+		ExpectedStdout: "node[1-3]\n",
+	},
+	{
+		Args:          []string{"-e", "node[1-3"},
+		ExpectedCode:  exitParse,
+		ExpectedError: true,
+	},
+	{
+		Args:         []string{"-q", "-e", "node[1-3"},
+		ExpectedCode: exitParse,
+	},
+	{
+		Args:          []string{"-e", ""},
+		ExpectedCode:  exitParse,
+		ExpectedError: true,
+	},
+	{
+		Args:          []string{"-e"},
+		Stdin:         "\n",
+		ExpectedCode:  exitParse,
+		ExpectedError: true,
+	},
+	{
+		Args:          []string{"-c", ","},
+		ExpectedCode:  exitParse,
+		ExpectedError: true,
+	},
+	{
+		Args:          []string{"-e", "-limit", "2", "node[1-3]"},
+		ExpectedCode:  exitLimit,
+		ExpectedError: true,
+	},
+	{
+		Args:         []string{"-q", "-e", "node[1-99999999999999999999999]"},
+		ExpectedCode: exitLimit,
+	},
+	{
+		Args:          []string{"-e", "-c", "node1"},
+		ExpectedCode:  exitUsage,
+		ExpectedError: true,
+	},
+	{
+		Args:          []string{"-unknown"},
+		ExpectedCode:  exitUsage,
+		ExpectedError: true,
+	},
+	{
+		Args:         []string{"-q", "-bogus"},
+		ExpectedCode: exitUsage,
+	},
+	{
+		Args:          []string{"-h"},
+		ExpectedCode:  0,
+		ExpectedError: true,
+	},
+	{
+		Args:          []string{"-e", "-f", "missing-hostfile"},
+		ExpectedCode:  exitInput,
+		ExpectedError: true,
+	},
+	{
+		Args:         []string{"tree", "-q", "-format", "unknown", "a1"},
+		ExpectedCode: exitUsage,
+	},
+	{
+		Args:         []string{"tree", "-q", "-bogus"},
+		ExpectedCode: exitUsage,
+	},
+	{
+		Args:          []string{"tree", "-bogus"},
+		ExpectedCode:  exitUsage,
+		ExpectedError: true,
+	},
+	{
+		Args:          []string{"tree"},
+		ExpectedCode:  exitParse,
+		ExpectedError: true,
+	},
+}
+
+// TestRun tests the output and the exit code of the command
+func TestRun(t *testing.T) {
+	for _, c := range RunTestcases {
+		t.Logf("Testcase: args: %q stdin: %q\n", c.Args, c.Stdin)
+
+		stdout, stderr := &strings.Builder{}, &strings.Builder{}
+		cmd := &command{Name: "hostlist", Stdin: strings.NewReader(c.Stdin), Stdout: stdout, Stderr: stderr}
+		code := cmd.run(c.Args)
+		if code != c.ExpectedCode {
+			t.Fatalf("Invalid exit code: actual: %d expect: %d stderr: %s", code, c.ExpectedCode, stderr)
+		}
+		if stdout.String() != c.ExpectedStdout {
+			t.Fatalf("Invalid stdout: actual: %q expect: %q", stdout, c.ExpectedStdout)
+		}
+		if (stderr.Len() > 0) != c.ExpectedError {
+			t.Fatalf("Invalid stderr: actual: %q expect error: %t", stderr, c.ExpectedError)
+		}
+	}
+}
+
+// TestExitCode tests the exit codes of errors from expanding or compressing hostnames
+func TestExitCode(t *testing.T) {
+	testcases := map[error]int{
+		expand.ErrTooManyHosts:                          exitLimit,
+		fmt.Errorf("%w: %d", errLimitExceeded, 10):      exitLimit,
+		expand.ErrEmptyExpression:                       exitParse,
+		expand.ErrInvalidToken{Token: '!', Position: 1}: exitParse,
+	}
+	for err, expected := range testcases {
+		t.Logf("Testcase: %s\n", err)
+		if code := exitCode(err); code != expected {
+			t.Fatalf("Invalid exit code: actual: %d expect: %d", code, expected)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package compress
+
+// MaxPermutationDims is the maximum number of numeric fields for which FoldHostsMinimal
+// tries every folding order. For templates with more fields, only rotations of the
+// default folding order are tried.
+const MaxPermutationDims = 5
+
+// CostFunc returns the cost of a list of hostlist expressions. A lower cost is better.
+// A CostFunc should be additive, i.e., the cost of a list of expressions is the sum of
+// the costs of its parts, as FoldHostsMinimal minimizes the cost of each template separately.
+type CostFunc func(expressions []string) int
+
+// LengthCost returns the length of the expressions joined by ','
+func LengthCost(expressions []string) int {
+	if len(expressions) == 0 {
+		return 0
+	}
+
+	cost := len(expressions) - 1 // ',' separators
+	for _, expr := range expressions {
+		cost += len(expr)
+	}
+	return cost
+}
+
+// GroupCost returns the number of top-level expressions
+func GroupCost(expressions []string) int {
+	return len(expressions)
+}
+
+// FoldHostsMinimal returns a hostlist expression representing the list of hosts with the lowest
+// cost according to the cost function. If cost is nil, LengthCost is used.
+//
+// FoldHostsMinimal searches for the best result of FoldHosts by folding the numeric fields
+// of each template in different orders.
+//
+// For example, `rack1-node1`, `rack1-node2`, `rack2-node1`, `rack2-node2`, `rack3-node1`, `rack3-node2`,
+// `rack3-node3` becomes `rack[1-3]-node[1-2],rack3-node3` instead of `rack[1-2]-node[1-2],rack3-node[1-3]`.
+func FoldHostsMinimal(hosts []string, cost CostFunc) string {
+	if cost == nil {
+		cost = LengthCost
+	}
+
+	groups := buildFoldGroups(hosts)
+
+	items := []foldResult{}
+	for _, g := range groups {
+		var best []foldResult
+		bestCost := 0
+
+		for _, order := range foldOrders(len(g.Literals) - 1) {
+			candidate := foldGroup{Literals: g.Literals, Items: foldItems(g.Items, order)}
+			results := candidate.results()
+
+			expressions := make([]string, len(results))
+			for i, r := range results {
+				expressions[i] = r.Expression
+			}
+
+			if c := cost(expressions); best == nil || c < bestCost {
+				best = results
+				bestCost = c
+			}
+		}
+		items = append(items, best...)
+	}
+
+	return joinFoldResults(items)
+}
+
+// foldOrders returns the list of orders for folding n dimensions. The first order is
+// always the default order used by FoldHosts.
+func foldOrders(n int) [][]int {
+	if n == 0 {
+		return [][]int{nil}
+	}
+
+	base := make([]int, n)
+	for i := range base {
+		base[i] = n - 1 - i
+	}
+
+	orders := [][]int{}
+	if n > MaxPermutationDims {
+		for i := 0; i < n; i++ {
+			order := append(append([]int{}, base[i:]...), base[:i]...)
+			orders = append(orders, order)
+		}
+		return orders
+	}
+
+	var permute func(order []int, k int)
+	permute = func(order []int, k int) {
+		if k == len(order) {
+			orders = append(orders, append([]int{}, order...))
+			return
+		}
+		for i := k; i < len(order); i++ {
+			order[k], order[i] = order[i], order[k]
+			permute(order, k+1)
+			order[k], order[i] = order[i], order[k]
+		}
+	}
+	permute(base, 0)
+
+	return orders
+}
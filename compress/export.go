@@ -0,0 +1,95 @@
+package compress
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/puttsk/hostlist/utils"
+)
+
+// tokenNodeJSON represents the JSON structure of a TokenNode
+type tokenNodeJSON struct {
+	Type     string          `json:"type"`
+	Value    string          `json:"value"`
+	Int      json.Number     `json:"int,omitempty"`     // For NumberToken only. Numbers can be longer than 64 bits
+	Padding  int             `json:"padding,omitempty"` // Width of zero padded number. For NumberToken only
+	Terminal bool            `json:"terminal,omitempty"`
+	Children []tokenNodeJSON `json:"children"`
+}
+
+// tokenTypeNames maps TokenType to the name used in exported structures
+var tokenTypeNames = map[TokenType]string{
+	RootToken:    "root",
+	RuneToken:    "rune",
+	NumberToken:  "number",
+	LiteralToken: "literal",
+}
+
+func (n TokenNode) toJSON() tokenNodeJSON {
+	n.sortChildren()
+	node := tokenNodeJSON{
+		Type:     tokenTypeNames[n.Token.Type],
+		Value:    n.Token.Value,
+		Terminal: n.Terminal,
+		Children: make([]tokenNodeJSON, len(n.Children)),
+	}
+	if n.Token.Type == NumberToken {
+		node.Int = json.Number(utils.TrimZeros(n.Token.Value))
+		if n.Token.ZeroPadded {
+			node.Padding = len(n.Token.Value)
+		}
+	}
+	for i, c := range n.Children {
+		node.Children[i] = c.toJSON()
+	}
+	return node
+}
+
+// MarshalJSON returns the JSON encoding of TokenNode n and its children.
+//
+// For example, the node of `a1` and `a2` is encoded as:
+//
+//	{"type":"literal","value":"a","children":[{"type":"number","value":"1","int":1,"terminal":true,"children":[]},...]}
+func (n TokenNode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(n.toJSON())
+}
+
+// MarshalJSON returns the JSON encoding of the tree structure starting from the root node
+func (t HostlistExpressionTree) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Root.toJSON())
+}
+
+// DOT returns the structure of the tree in Graphviz DOT language.
+// Number tokens are drawn as boxes, and nodes where hostnames end are drawn with double borders.
+func (t HostlistExpressionTree) DOT() string {
+	builder := strings.Builder{}
+	builder.WriteString("digraph hostlist {\n")
+
+	id := 0
+	var writeNode func(n *TokenNode) int
+	writeNode = func(n *TokenNode) int {
+		nodeID := id
+		id++
+
+		attrs := []string{fmt.Sprintf("label=%q", n.Token.Value)}
+		if n.Token.Type == NumberToken {
+			attrs = append(attrs, "shape=box")
+		}
+		if n.Terminal {
+			attrs = append(attrs, "peripheries=2")
+		}
+		builder.WriteString(fmt.Sprintf("\tn%d [%s];\n", nodeID, strings.Join(attrs, ",")))
+
+		n.sortChildren()
+		for _, c := range n.Children {
+			childID := writeNode(c)
+			builder.WriteString(fmt.Sprintf("\tn%d -> n%d;\n", nodeID, childID))
+		}
+		return nodeID
+	}
+	writeNode(t.Root)
+
+	builder.WriteString("}\n")
+	return builder.String()
+}
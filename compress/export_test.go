@@ -0,0 +1,86 @@
+package compress_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/puttsk/hostlist/compress"
+)
+
+var MarshalJSONTestcases = []CompressHostlistTestcase{
+	{
+		Hostlist:       []string{},
+		ExpectedResult: `{"type":"root","value":"*","children":[]}`,
+	},
+	{
+		Hostlist:       []string{"a", "a01"},
+		ExpectedResult: `{"type":"root","value":"*","children":[{"type":"literal","value":"a","terminal":true,"children":[{"type":"number","value":"01","int":1,"padding":2,"terminal":true,"children":[]}]}]}`,
+	},
+	{
+		Hostlist:       []string{"b2", "b1"},
+		ExpectedResult: `{"type":"root","value":"*","children":[{"type":"literal","value":"b","children":[{"type":"number","value":"1","int":1,"terminal":true,"children":[]},{"type":"number","value":"2","int":2,"terminal":true,"children":[]}]}]}`,
+	},
+}
+
+// TestMarshalJSON tests HostlistExpressionTree.MarshalJSON
+func TestMarshalJSON(t *testing.T) {
+	for _, c := range MarshalJSONTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+
+		tree := compress.NewHostlistExpressionTree()
+		for _, h := range c.Hostlist {
+			tree.AddHost(h)
+		}
+
+		result, err := json.Marshal(tree)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if string(result) != c.ExpectedResult {
+			t.Fatalf("Invalid JSON: actual:\n%s\nexpect:\n%s\n", result, c.ExpectedResult)
+		}
+	}
+}
+
+var DOTTestcases = []CompressHostlistTestcase{
+	{
+		Hostlist: []string{},
+		ExpectedResult: `digraph hostlist {
+	n0 [label="*"];
+}
+`,
+	},
+	{
+		Hostlist: []string{"a1", "a2", "b"},
+		ExpectedResult: `digraph hostlist {
+	n0 [label="*"];
+	n1 [label="a"];
+	n2 [label="1",shape=box,peripheries=2];
+	n1 -> n2;
+	n3 [label="2",shape=box,peripheries=2];
+	n1 -> n3;
+	n0 -> n1;
+	n4 [label="b",peripheries=2];
+	n0 -> n4;
+}
+`,
+	},
+}
+
+// TestDOT tests HostlistExpressionTree.DOT
+func TestDOT(t *testing.T) {
+	for _, c := range DOTTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+
+		tree := compress.NewHostlistExpressionTree()
+		for _, h := range c.Hostlist {
+			tree.AddHost(h)
+		}
+
+		result := tree.DOT()
+		if result != c.ExpectedResult {
+			t.Fatalf("Invalid DOT: actual:\n%s\nexpect:\n%s\n", result, c.ExpectedResult)
+		}
+	}
+}
@@ -1,8 +1,20 @@
 package compress
 
-// HostlistExpressionTree represents a syntax tree of a hostlist expression
+import (
+	"slices"
+	"strings"
+
+	"github.com/puttsk/hostlist/utils"
+)
+
+// HostlistExpressionTree represents a syntax tree of a hostlist expression.
+//
+// The tree can be updated incrementally with AddHost and RemoveHost. Each node caches its
+// hostlist expression, which is discarded only when the node or one of its descendants
+// is modified. The tree should not be modified directly through TokenNode.
 type HostlistExpressionTree struct {
-	Root *TokenNode
+	Root    *TokenNode
+	Options Options // Options for generating hostlist expression
 	//Leaves [][]*TokenNode // [level][]Token
 }
 
@@ -14,32 +26,180 @@ func NewHostlistExpressionTree() *HostlistExpressionTree {
 	}
 }
 
-// AddHost adds a new host to and restructure the HostlistExpressionTree
+// AddHost adds a new host to and restructure the HostlistExpressionTree. An empty host is ignored.
+// Children of each node are sorted in natural order, i.e., number tokens are compared
+// by their integer values, so the structure of the tree does not depend on the order of
+// the hosts being added. The children are sorted when the tree is read, so adding a host
+// takes constant time per token regardless of the order of the hosts.
+//
+// The tree is path-compressed: a run of non-digit characters is stored in a single node,
+// which is split when another host diverges in the middle of the run.
 func (t *HostlistExpressionTree) AddHost(host string) {
-	tokens := Tokenize(host)
+	if host == "" {
+		return
+	}
+	tokens := tokenizeLiterals(host)
 
 	head := t.Root
+	head.invalidate()
 	for _, token := range tokens {
-		found := false
-		for j, child := range head.Children {
-			if child.Token == token {
-				found = true
-				head = head.Children[j]
-			}
+		if token.Type == LiteralToken {
+			head = head.insertLiteral(token.Value)
+			continue
+		}
+
+		child := head.findChild(token)
+		if child == nil {
+			child = NewTokenNode(token)
+			child.Level = head.Level + 1
+			head.addChild(child)
 		}
+		head = child
+		head.invalidate()
+	}
+	head.Terminal = true
+}
+
+// AddHosts adds a list of hosts to the HostlistExpressionTree. The hosts are inserted in natural
+// order, so new nodes are appended to the end of the children, and the time to build the tree
+// is O(n log n) for n hosts. The list is not modified.
+func (t *HostlistExpressionTree) AddHosts(hosts []string) {
+	sorted := hosts
+	if !slices.IsSortedFunc(hosts, utils.NaturalCompare) {
+		sorted = slices.Clone(hosts)
+		slices.SortFunc(sorted, utils.NaturalCompare)
+	}
+	for _, h := range sorted {
+		t.AddHost(h)
+	}
+}
 
-		if !found {
-			// Create a new Character node
+// insertLiteral inserts a run of non-digit characters below node n, splitting an existing
+// literal node if it shares only a part of the run. insertLiteral returns the node at the
+// end of the run.
+func (n *TokenNode) insertLiteral(literal string) *TokenNode {
+	head := n
+	for literal != "" {
+		token := NewToken(LiteralToken, literal)
+		child := head.findChild(token)
+		if child == nil {
 			node := NewTokenNode(token)
 			node.Level = head.Level + 1
-			head.Children = append(head.Children, node)
-			head = head.Children[len(head.Children)-1]
+			head.addChild(node)
+			return node
+		}
+
+		p := commonPrefix(child.Token.Value, literal)
+		if p < len(child.Token.Value) {
+			// Split the child into the common prefix and the remaining characters. The child keeps
+			// the common prefix, so its position and its key in the parent do not change.
+			rest := &TokenNode{
+				Token:    NewToken(LiteralToken, child.Token.Value[p:]),
+				Children: child.Children,
+				Level:    child.Level,
+				Terminal: child.Terminal,
+				index:    child.index,
+				unsorted: child.unsorted,
+			}
+			rest.shiftLevel(1)
+
+			child.Token = NewToken(LiteralToken, child.Token.Value[:p])
+			child.Children = []*TokenNode{rest}
+			child.Terminal = false
+			child.index = nil
+			child.unsorted = false
+		}
+
+		head = child
+		head.invalidate()
+		literal = literal[p:]
+	}
+	return head
+}
+
+// RemoveHost removes a host from the HostlistExpressionTree. Nodes which no longer lead to
+// any host are removed from the tree, and a literal node left with a single literal child
+// is merged with its child. Only the cached expressions of the nodes on the path to the
+// host are discarded, so the next call to GetExpression recomputes only that path.
+// RemoveHost returns false if the host is not in the tree.
+func (t *HostlistExpressionTree) RemoveHost(host string) bool {
+	tokens := tokenizeLiterals(host)
+
+	// Nodes from the root to the host
+	path := make([]*TokenNode, 0, len(tokens)+1)
+	path = append(path, t.Root)
+
+	head := t.Root
+	for _, token := range tokens {
+		literal := token.Value
+		for {
+			child := head.findChild(token)
+			if child == nil || !strings.HasPrefix(literal, child.Token.Value) {
+				return false
+			}
+			head = child
+			path = append(path, head)
+
+			// A run of characters can span multiple literal nodes
+			literal = literal[len(head.Token.Value):]
+			if token.Type != LiteralToken || literal == "" {
+				break
+			}
+			token = NewToken(LiteralToken, literal)
+		}
+	}
+
+	if !head.Terminal {
+		return false
+	}
+	head.Terminal = false
+
+	for i := len(path) - 1; i >= 0; i-- {
+		node := path[i]
+		node.invalidate()
+		if i == 0 {
+			break
+		}
+
+		if !node.Terminal && len(node.Children) == 0 {
+			// Remove node without hosts from its parent
+			parent := path[i-1]
+			parent.removeChild(node)
+		} else if node.Token.Type == LiteralToken && !node.Terminal && len(node.Children) == 1 && node.Children[0].Token.Type == LiteralToken {
+			// Merge literal node with its only literal child
+			child := node.Children[0]
+			node.Token = NewToken(LiteralToken, node.Token.Value+child.Token.Value)
+			node.Terminal = child.Terminal
+			node.Children = child.Children
+			node.index = child.index
+			node.unsorted = child.unsorted
+			for _, c := range node.Children {
+				c.shiftLevel(-1)
+			}
 		}
 	}
+	return true
 }
 
+// GetExpression returns a hostlist expression representing the hosts in the tree
 func (t HostlistExpressionTree) GetExpression() string {
-	return t.Root.GetExpression()
+	expr, _ := t.Root.GetExpressionWithOptions(t.Options)
+	return expr
+}
+
+// GetExpressionWithWarnings returns a hostlist expression representing the hosts in the tree,
+// and a list of numbers which could not be joined into range expressions because of their
+// zero padding.
+func (t HostlistExpressionTree) GetExpressionWithWarnings() (string, []PaddingWarning) {
+	return t.Root.GetExpressionWithOptions(t.Options)
+}
+
+// GetExpressionWithReport returns a hostlist expression representing the hosts in the tree,
+// and a report explaining why some hosts could not be combined into a single range expression.
+func (t HostlistExpressionTree) GetExpressionWithReport() (string, Report) {
+	ctx := &expressionContext{Options: t.Options}
+	expr := t.Root.getExpression(ctx)
+	return expr, Report{PaddingWarnings: ctx.Warnings, SuffixConflicts: ctx.Conflicts}
 }
 
 func (t HostlistExpressionTree) String() string {
@@ -0,0 +1,225 @@
+package compress_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/puttsk/hostlist/compress"
+)
+
+// TreeOperation represents adding or removing a host from HostlistExpressionTree
+type TreeOperation struct {
+	Host           string
+	Remove         bool
+	ExpectedFound  bool // Expected return value of RemoveHost
+	ExpectedResult string
+}
+
+var IncrementalTreeTestcases = [][]TreeOperation{
+	{
+		{Host: "node1", ExpectedResult: "node1"},
+		{Host: "node2", ExpectedResult: "node[1-2]"},
+		{Host: "node3", ExpectedResult: "node[1-3]"},
+		{Host: "node2", Remove: true, ExpectedFound: true, ExpectedResult: "node[1,3]"},
+		{Host: "node2", Remove: true, ExpectedFound: false, ExpectedResult: "node[1,3]"},
+		{Host: "node1", Remove: true, ExpectedFound: true, ExpectedResult: "node3"},
+		{Host: "node3", Remove: true, ExpectedFound: true, ExpectedResult: ""},
+		{Host: "node4", ExpectedResult: "node4"},
+	},
+	{
+		{Host: "host", ExpectedResult: "host"},
+		{Host: "host1", ExpectedResult: "host[,1]"},
+		{Host: "host", Remove: true, ExpectedFound: true, ExpectedResult: "host1"},
+		{Host: "hos", Remove: true, ExpectedFound: false, ExpectedResult: "host1"},
+		{Host: "host", ExpectedResult: "host[,1]"},
+		{Host: "host1", Remove: true, ExpectedFound: true, ExpectedResult: "host"},
+	},
+	{
+		{Host: "rack1-node1", ExpectedResult: "rack1-node1"},
+		{Host: "rack1-node2", ExpectedResult: "rack1-node[1-2]"},
+		{Host: "rack1-node3", ExpectedResult: "rack1-node[1-3]"},
+		{Host: "rack1-node", Remove: true, ExpectedFound: false, ExpectedResult: "rack1-node[1-3]"},
+		{Host: "rack1-node3", Remove: true, ExpectedFound: true, ExpectedResult: "rack1-node[1-2]"},
+		{Host: "rack1-node12", Remove: true, ExpectedFound: false, ExpectedResult: "rack1-node[1-2]"},
+	},
+}
+
+// TestIncrementalTree tests HostlistExpressionTree.AddHost and HostlistExpressionTree.RemoveHost
+// by checking the expression after every operation
+func TestIncrementalTree(t *testing.T) {
+	for _, c := range IncrementalTreeTestcases {
+		tree := compress.NewHostlistExpressionTree()
+		for _, op := range c {
+			if op.Remove {
+				t.Logf("Testcase: remove %s\n", op.Host)
+				found := tree.RemoveHost(op.Host)
+				if found != op.ExpectedFound {
+					t.Fatalf("Invalid return value: actual: %v expect: %v", found, op.ExpectedFound)
+				}
+			} else {
+				t.Logf("Testcase: add %s\n", op.Host)
+				tree.AddHost(op.Host)
+			}
+
+			result := tree.GetExpression()
+			if result != op.ExpectedResult {
+				t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", result, op.ExpectedResult)
+			}
+		}
+	}
+}
+
+var RadixTreeTestcases = []TreeOperation{
+	{Host: "compute-gpu-a100-node-01", ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}`},
+	{Host: "compute-gpu-a100-node-02", ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                        {D:02}`},
+	{Host: "compute-cpu-01", ExpectedResult: `{L:compute-}->{L:cpu-}->{D:01}
+              {L:gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                              {D:02}`},
+	{Host: "compute-", ExpectedResult: `{L:compute-}->{L:cpu-}->{D:01}
+              {L:gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                              {D:02}`},
+	{Host: "compute-cpu-01", Remove: true, ExpectedFound: true, ExpectedResult: `{L:compute-}->{L:gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                              {D:02}`},
+	{Host: "compute-", Remove: true, ExpectedFound: true, ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                        {D:02}`},
+	{Host: "compute-gpu", Remove: true, ExpectedFound: false, ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                        {D:02}`},
+	{Host: "nœud", ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                        {D:02}
+{L:nœud}`},
+	{Host: "nöd", ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                        {D:02}
+{L:n}->{L:öd}
+       {L:œud}`},
+	{Host: "nöd", Remove: true, ExpectedFound: true, ExpectedResult: `{L:compute-gpu-a}->{D:100}->{L:-node-}->{D:01}
+                                        {D:02}
+{L:nœud}`},
+}
+
+// TestRadixTree tests that HostlistExpressionTree splits and merges literal nodes
+// when adding and removing hosts
+func TestRadixTree(t *testing.T) {
+	tree := compress.NewHostlistExpressionTree()
+	for _, op := range RadixTreeTestcases {
+		if op.Remove {
+			t.Logf("Testcase: remove %s\n", op.Host)
+			found := tree.RemoveHost(op.Host)
+			if found != op.ExpectedFound {
+				t.Fatalf("Invalid return value: actual: %v expect: %v", found, op.ExpectedFound)
+			}
+		} else {
+			t.Logf("Testcase: add %s\n", op.Host)
+			tree.AddHost(op.Host)
+		}
+
+		result := strings.TrimSpace(tree.String())
+		if result != op.ExpectedResult {
+			t.Fatalf("Invalid tree: actual:\n%s\nexpect:\n%s\n", result, op.ExpectedResult)
+		}
+	}
+}
+
+// TestIncrementalTreeConsistency randomly adds and removes hosts, checking that the tree is
+// the same as a tree built from the remaining hosts
+func TestIncrementalTreeConsistency(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	parts := []string{"a", "ab", "abc", "b-", "-", "1", "01", "2", "10", "ö"}
+
+	tree := compress.NewHostlistExpressionTree()
+	hosts := map[string]bool{}
+	for i := 0; i < 500; i++ {
+		host := ""
+		for j := rng.Intn(4) + 1; j > 0; j-- {
+			host += parts[rng.Intn(len(parts))]
+		}
+
+		if rng.Intn(3) == 0 {
+			if tree.RemoveHost(host) != hosts[host] {
+				t.Fatalf("Invalid return value of RemoveHost(%s): expect: %v", host, hosts[host])
+			}
+			delete(hosts, host)
+		} else {
+			tree.AddHost(host)
+			hosts[host] = true
+		}
+
+		expected := compress.NewHostlistExpressionTree()
+		for h := range hosts {
+			expected.AddHost(h)
+		}
+
+		if tree.String() != expected.String() {
+			t.Fatalf("Invalid tree after %s: actual:\n%s\nexpect:\n%s\n", host, tree, expected)
+		}
+		if tree.GetExpression() != expected.GetExpression() {
+			t.Fatalf("Invalid expression after %s: actual:\n%s\nexpect:\n%s\n", host, tree.GetExpression(), expected.GetExpression())
+		}
+	}
+}
+
+// TestIncrementalTreeWideLevels adds and removes hosts in random order on levels with many
+// children, checking that the tree is the same as a tree built from the sorted hosts
+func TestIncrementalTreeWideLevels(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+
+	hosts := []string{}
+	for i := 0; i < 200; i++ {
+		hosts = append(hosts, fmt.Sprintf("n%d", i), fmt.Sprintf("n%03d-%c", i, 'a'+rune(i%26)))
+	}
+	for i := 0; i < 26*7; i++ {
+		hosts = append(hosts, fmt.Sprintf("%c%d", 'A'+rune(i%26), i%7))
+	}
+	rng.Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+
+	tree := compress.NewHostlistExpressionTree()
+	for _, h := range hosts {
+		tree.AddHost(h)
+	}
+	for _, h := range hosts[:len(hosts)/3] {
+		tree.RemoveHost(h)
+	}
+	remaining := hosts[len(hosts)/3:]
+
+	expected := compress.NewHostlistExpressionTree()
+	expected.AddHosts(remaining)
+
+	if tree.String() != expected.String() {
+		t.Fatalf("Invalid tree: actual:\n%s\nexpect:\n%s\n", tree, expected)
+	}
+	if tree.GetExpression() != expected.GetExpression() {
+		t.Fatalf("Invalid expression: actual: %s expect: %s", tree.GetExpression(), expected.GetExpression())
+	}
+	for _, h := range remaining {
+		if !tree.RemoveHost(h) {
+			t.Fatalf("Hostname not found: %s", h)
+		}
+	}
+	if tree.GetExpression() != "" {
+		t.Fatalf("Invalid expression: actual: %s expect: %s", tree.GetExpression(), "")
+	}
+}
+
+// TestAddHosts tests that HostlistExpressionTree.AddHosts builds the same tree as AddHost
+func TestAddHosts(t *testing.T) {
+	hosts := []string{"b10", "a", "b9", "a-1", "b09", "a1", "c", "b10-x", "a-"}
+
+	expected := compress.NewHostlistExpressionTree()
+	for _, h := range hosts {
+		expected.AddHost(h)
+	}
+
+	tree := compress.NewHostlistExpressionTree()
+	tree.AddHosts(hosts)
+	if tree.String() != expected.String() {
+		t.Fatalf("Invalid tree: actual: %s expect: %s", tree, expected)
+	}
+	if tree.GetExpression() != expected.GetExpression() {
+		t.Fatalf("Invalid expression: actual: %s expect: %s", tree.GetExpression(), expected.GetExpression())
+	}
+	if hosts[0] != "b10" {
+		t.Fatalf("Invalid hosts: actual: %v", hosts)
+	}
+}
@@ -0,0 +1,207 @@
+package compress
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/puttsk/hostlist/utils"
+)
+
+// foldItem represents a rectangular set of hostnames sharing the same literal template.
+// Each dimension contains the sorted number tokens of one numeric field.
+type foldItem struct {
+	First string    // The first hostname in natural order
+	Dims  [][]Token // [dimension][]Token
+}
+
+// foldGroup represents hostnames with the same literal template, i.e., the same
+// non-numeric parts and the same number of numeric fields.
+type foldGroup struct {
+	Literals []string // Literal parts around numeric fields. len(Literals) == number of fields + 1
+	Items    []*foldItem
+}
+
+// splitTemplate splits tokens into literal parts and numeric fields.
+func splitTemplate(tokens []Token) ([]string, []Token) {
+	literals := make([]string, 1, len(tokens)+1)
+	numbers := make([]Token, 0, len(tokens))
+	for _, tok := range tokens {
+		if tok.Type == NumberToken {
+			numbers = append(numbers, tok)
+			literals = append(literals, "")
+		} else {
+			literals[len(literals)-1] += tok.Value
+		}
+	}
+	return literals, numbers
+}
+
+// FoldHosts returns a hostlist expression representing the list of hosts using
+// multi-dimensional folding.
+//
+// Hostnames are grouped by their literal template, e.g., `rack#-node#`, and the numeric
+// fields of each group are folded into rectangular Cartesian products. Unlike
+// TokenNode.GetExpression, the result never contains a nested range expression.
+//
+// For example:
+//
+//	`rack1-node01`, `rack1-node02`, `rack2-node01`, `rack2-node02` becomes `rack[1-2]-node[01-02]`
+func FoldHosts(hosts []string) string {
+	groups := buildFoldGroups(hosts)
+
+	items := []foldResult{}
+	for _, g := range groups {
+		g.Items = foldItems(g.Items, nil)
+		items = append(items, g.results()...)
+	}
+
+	return joinFoldResults(items)
+}
+
+// buildFoldGroups creates a foldGroup for each literal template in hosts.
+// Duplicated and empty hostnames are ignored.
+func buildFoldGroups(hosts []string) []*foldGroup {
+	groups := []*foldGroup{}
+	groupMap := map[string]*foldGroup{}
+	seen := map[string]bool{}
+
+	for _, h := range hosts {
+		if h == "" || seen[h] {
+			continue
+		}
+		seen[h] = true
+
+		literals, numbers := splitTemplate(tokenizeLiterals(h))
+
+		// Use NUL as separator as it cannot appear in hostnames
+		key := strings.Join(literals, "\x00")
+		g, ok := groupMap[key]
+		if !ok {
+			g = &foldGroup{Literals: literals}
+			groupMap[key] = g
+			groups = append(groups, g)
+		}
+
+		item := &foldItem{First: h, Dims: make([][]Token, len(numbers))}
+		for i := range numbers {
+			item.Dims[i] = numbers[i : i+1 : i+1]
+		}
+		g.Items = append(g.Items, item)
+	}
+	return groups
+}
+
+// foldItems repeatedly merges items along each dimension until no more items can be merged.
+// Dimensions are folded in the given order. If order is nil, dimensions are folded from
+// the last to the first one.
+func foldItems(items []*foldItem, order []int) []*foldItem {
+	if len(items) == 0 || len(items[0].Dims) == 0 {
+		return items
+	}
+
+	if order == nil {
+		for d := len(items[0].Dims) - 1; d >= 0; d-- {
+			order = append(order, d)
+		}
+	}
+
+	for {
+		count := len(items)
+		for _, d := range order {
+			items = foldDimension(items, d)
+		}
+		if len(items) == count {
+			return items
+		}
+	}
+}
+
+// foldDimension merges items having identical numeric fields in every dimension except d.
+// The tokens of dimension d are collected first and sorted once per merged item, so folding
+// n items takes O(n log n) time.
+func foldDimension(items []*foldItem, d int) []*foldItem {
+	result := []*foldItem{}
+	index := map[string]*foldItem{}
+	merging := map[*foldItem]bool{}
+
+	keyBuilder := strings.Builder{}
+	for _, item := range items {
+		// A sorted list of tokens is identified by the values of its tokens
+		keyBuilder.Reset()
+		for i, dim := range item.Dims {
+			if i != d {
+				for _, tok := range dim {
+					keyBuilder.WriteString(tok.Value)
+					keyBuilder.WriteByte(',')
+				}
+			}
+			keyBuilder.WriteByte(0)
+		}
+		key := keyBuilder.String()
+
+		merged, ok := index[key]
+		if !ok {
+			dims := slices.Clone(item.Dims)
+			merged = &foldItem{First: item.First, Dims: dims}
+			index[key] = merged
+			result = append(result, merged)
+			continue
+		}
+
+		if !merging[merged] {
+			merging[merged] = true
+			merged.Dims[d] = slices.Clone(merged.Dims[d])
+		}
+		merged.Dims[d] = append(merged.Dims[d], item.Dims[d]...)
+		if utils.NaturalCompare(item.First, merged.First) < 0 {
+			merged.First = item.First
+		}
+	}
+
+	for merged := range merging {
+		slices.SortFunc(merged.Dims[d], compareTokens)
+		merged.Dims[d] = slices.CompactFunc(merged.Dims[d], func(a, b Token) bool { return a.Value == b.Value })
+	}
+	return result
+}
+
+// foldResult represents a folded expression and the first hostname it contains
+type foldResult struct {
+	First      string
+	Expression string
+}
+
+// results returns the hostlist expressions of the items in the group
+func (g foldGroup) results() []foldResult {
+	results := make([]foldResult, len(g.Items))
+	for i, item := range g.Items {
+		builder := strings.Builder{}
+		builder.WriteString(g.Literals[0])
+		for d, dim := range item.Dims {
+			if len(dim) == 1 {
+				builder.WriteString(dim[0].Value)
+			} else {
+				builder.WriteByte('[')
+				builder.WriteString(rangeExpression(dim, Options{}, nil))
+				builder.WriteByte(']')
+			}
+			builder.WriteString(g.Literals[d+1])
+		}
+		results[i] = foldResult{First: item.First, Expression: builder.String()}
+	}
+	return results
+}
+
+// joinFoldResults sorts expressions by their first hostname in natural order and
+// joins them into a single hostlist expression.
+func joinFoldResults(results []foldResult) string {
+	slices.SortFunc(results, func(a, b foldResult) int {
+		return utils.NaturalCompare(a.First, b.First)
+	})
+
+	expressions := make([]string, len(results))
+	for i, r := range results {
+		expressions[i] = r.Expression
+	}
+	return strings.Join(expressions, ",")
+}
@@ -0,0 +1,133 @@
+package compress_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/puttsk/hostlist/compress"
+)
+
+var FoldHostsTestcases = []CompressHostlistTestcase{
+	{
+		Hostlist:       []string{},
+		ExpectedResult: "",
+	},
+	{
+		Hostlist:       []string{"aaaaa"},
+		ExpectedResult: "aaaaa",
+	},
+	{
+		Hostlist:       []string{"aa", "ab"},
+		ExpectedResult: "aa,ab",
+	},
+	{
+		Hostlist:       []string{"7", "8", "9", "10", "11", "9"},
+		ExpectedResult: "[7-11]",
+	},
+	{
+		Hostlist:       []string{"99b", "98b", "100b", "0101b"},
+		ExpectedResult: "[98-100,0101]b",
+	},
+	{
+		Hostlist:       []string{"192.168.1.1", "192.168.1.2", "192.168.2.1", "192.168.2.2"},
+		ExpectedResult: "192.168.[1-2].[1-2]",
+	},
+	{
+		Hostlist:       []string{"rack2-node02", "rack1-node01", "rack2-node01", "rack1-node02", "rack3-node01", "rack3-node02"},
+		ExpectedResult: "rack[1-3]-node[01-02]",
+	},
+	{
+		Hostlist:       []string{"rack1-node1", "rack1-node2", "rack1-node3", "rack2-node1", "rack2-node2"},
+		ExpectedResult: "rack1-node[1-3],rack2-node[1-2]",
+	},
+	{
+		Hostlist:       []string{"r1-n1", "r1-n2", "r2-n1", "r2-n2", "r3-n5"},
+		ExpectedResult: "r[1-2]-n[1-2],r3-n5",
+	},
+	{
+		Hostlist:       []string{"abcd", "abef", "abeg", "xyz", "x1z", "x2z"},
+		ExpectedResult: "abcd,abef,abeg,x[1-2]z,xyz",
+	},
+	{
+		Hostlist:       []string{"host-01", "a", "b", "host-03", "host-02", "10-host-120", "11-host-120", "zz-01-a", "yz-01-b", "yz-02-v", "yz-02x"},
+		ExpectedResult: "[10-11]-host-120,a,b,host-[01-03],yz-01-b,yz-02-v,yz-02x,zz-01-a",
+	},
+	{
+		Hostlist:       []string{"p1s1c1", "p1s1c2", "p1s2c1", "p1s2c2", "p2s1c1", "p2s1c2", "p2s2c1", "p2s2c2"},
+		ExpectedResult: "p[1-2]s[1-2]c[1-2]",
+	},
+}
+
+// TestFoldHosts tests compress.FoldHosts
+func TestFoldHosts(t *testing.T) {
+	for _, c := range FoldHostsTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+
+		result := compress.FoldHosts(c.Hostlist)
+
+		if result != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", result, c.ExpectedResult)
+		}
+	}
+}
+
+type FoldHostsMinimalTestcase struct {
+	Hostlist       []string
+	Cost           compress.CostFunc
+	ExpectedResult string
+}
+
+var FoldHostsMinimalTestcases = []FoldHostsMinimalTestcase{
+	{
+		Hostlist:       []string{},
+		Cost:           nil,
+		ExpectedResult: "",
+	},
+	{
+		Hostlist:       []string{"aa", "ab"},
+		Cost:           nil,
+		ExpectedResult: "aa,ab",
+	},
+	{
+		Hostlist:       []string{"rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2", "rack3-node1", "rack3-node2", "rack3-node3"},
+		Cost:           nil,
+		ExpectedResult: "rack[1-3]-node[1-2],rack3-node3",
+	},
+	{
+		Hostlist:       []string{"rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2", "rack3-node1", "rack3-node2", "rack3-node3"},
+		Cost:           compress.LengthCost,
+		ExpectedResult: "rack[1-3]-node[1-2],rack3-node3",
+	},
+	{
+		Hostlist:       []string{"rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2", "rack3-node1", "rack3-node2", "rack3-node3"},
+		Cost:           compress.GroupCost,
+		ExpectedResult: "rack[1-2]-node[1-2],rack3-node[1-3]",
+	},
+	{
+		// Avoid expressions representing a single host
+		Hostlist: []string{"rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2", "rack3-node1", "rack3-node2", "rack3-node3"},
+		Cost: func(expressions []string) int {
+			cost := 0
+			for _, expr := range expressions {
+				if !strings.Contains(expr, "[") {
+					cost++
+				}
+			}
+			return cost
+		},
+		ExpectedResult: "rack[1-2]-node[1-2],rack3-node[1-3]",
+	},
+}
+
+// TestFoldHostsMinimal tests compress.FoldHostsMinimal
+func TestFoldHostsMinimal(t *testing.T) {
+	for _, c := range FoldHostsMinimalTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+
+		result := compress.FoldHostsMinimal(c.Hostlist, c.Cost)
+
+		if result != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", result, c.ExpectedResult)
+		}
+	}
+}
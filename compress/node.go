@@ -2,18 +2,40 @@ package compress
 
 import (
 	"fmt"
-	"sort"
+	"slices"
 	"strings"
+	"unicode/utf8"
+
+	"github.com/puttsk/hostlist/utils"
 )
 
 // TokenNode represents a node in an expression tree
 // TokenNode can contain multiple tokens, if those tokens can be represented
-// in a range expression
+// in a range expression. In a HostlistExpressionTree, a node contains either a number
+// or a run of non-digit characters, i.e., the tree is path-compressed.
 type TokenNode struct {
 	Token              Token
 	Children           []*TokenNode
 	ChildredExpression string // Hostlist expression representing the children node.
 	Level              int
+	Terminal           bool // True if a hostname ends at this node
+
+	cache    expressionCache
+	index    map[string]*TokenNode // Children by childKey. Built when the node has many children
+	unsorted bool                  // True if Children are not sorted in natural order
+}
+
+// minIndexedChildren is the number of children from which a node indexes its children
+const minIndexedChildren = 16
+
+// expressionCache keeps the hostlist expression of a TokenNode until the node or one of its
+// descendants is modified.
+type expressionCache struct {
+	Valid      bool
+	Options    Options
+	Expression string
+	Warnings   []PaddingWarning
+	Conflicts  []SuffixConflict
 }
 
 // NewTokenNode initializes TokenNode with a Token t
@@ -24,6 +46,85 @@ func NewTokenNode(t Token) *TokenNode {
 	}
 }
 
+// findChild returns the child node containing token t, or nil if there is no such child.
+// For a literal token, findChild returns the literal child starting with the same character,
+// as no two literal children share their first character.
+//
+// The children of a node with many children are found through an index, so adding hosts
+// in any order takes constant time per token.
+func (n *TokenNode) findChild(t Token) *TokenNode {
+	if n.index != nil {
+		return n.index[childKey(t)]
+	}
+	for _, c := range n.Children {
+		if compareChild(c, t) == 0 {
+			return c
+		}
+	}
+	return nil
+}
+
+// addChild adds child node c to n. The children are sorted lazily by sortChildren, so
+// a child added out of order only marks the children as unsorted.
+func (n *TokenNode) addChild(c *TokenNode) {
+	if last := len(n.Children) - 1; last >= 0 && compareChild(n.Children[last], c.Token) > 0 {
+		n.unsorted = true
+	}
+	n.Children = append(n.Children, c)
+
+	if n.index != nil {
+		n.index[childKey(c.Token)] = c
+	} else if len(n.Children) >= minIndexedChildren {
+		n.index = make(map[string]*TokenNode, len(n.Children))
+		for _, child := range n.Children {
+			n.index[childKey(child.Token)] = child
+		}
+	}
+}
+
+// removeChild removes child node c from n
+func (n *TokenNode) removeChild(c *TokenNode) {
+	n.Children = slices.DeleteFunc(n.Children, func(child *TokenNode) bool { return child == c })
+	if n.index != nil {
+		delete(n.index, childKey(c.Token))
+	}
+}
+
+// sortChildren sorts the children of n in natural order if a child was added out of order
+func (n *TokenNode) sortChildren() {
+	if !n.unsorted {
+		return
+	}
+	slices.SortFunc(n.Children, func(a, b *TokenNode) int { return compareChild(a, b.Token) })
+	n.unsorted = false
+}
+
+// childKey returns the key of a child node containing token t in the index of its parent
+func childKey(t Token) string {
+	if t.Type == LiteralToken {
+		_, size := utf8.DecodeRuneInString(t.Value)
+		return t.Value[:size]
+	}
+	return t.Value
+}
+
+// compareChild compares the token of child node c with token t. Literal tokens are compared
+// by their first character.
+func compareChild(c *TokenNode, t Token) int {
+	if c.Token.Type == LiteralToken && t.Type == LiteralToken {
+		return strings.Compare(childKey(c.Token), childKey(t))
+	}
+	return compareTokens(c.Token, t)
+}
+
+// shiftLevel adds delta to the level of n and all of its descendants
+func (n *TokenNode) shiftLevel(delta int) {
+	n.Level += delta
+	for _, c := range n.Children {
+		c.shiftLevel(delta)
+	}
+}
+
 func (n TokenNode) String() string {
 	return fmt.Sprint(n.Token)
 }
@@ -62,6 +163,7 @@ func (n TokenNode) PrintNode() string {
 
 			// n is not leaf node.
 			if len(ptr.Node.Children) > 0 {
+				ptr.Node.sortChildren()
 				// Traverse the tree from the first node
 				for i := len(ptr.Node.Children) - 1; i >= 0; i-- {
 					// Put a marker when traverse back to parent
@@ -86,13 +188,13 @@ func (n TokenNode) PrintNode() string {
 				if !strings.Contains(visitedStr, prefixStr) {
 					// Check if there is overlap.
 					overlapped := 0
-					for i := len(visitedStr); i > 0; i-- {
+					for i := min(len(visitedStr), len(prefixStr)); i > 0; i-- {
 						if strings.Contains(visitedStr, prefixStr[len(prefixStr)-i:]) {
 							overlapped = i
 							break
 						}
 					}
-					indent = strings.Repeat(" ", len(prefixStr)-overlapped)
+					indent = strings.Repeat(" ", utf8.RuneCountInString(prefixStr[:len(prefixStr)-overlapped]))
 				}
 				builder.WriteString(indent + visitedStr + "\n")
 			}
@@ -104,93 +206,272 @@ func (n TokenNode) PrintNode() string {
 }
 
 // GetExpression returns a hostlist expression representing the TokenNode.
+//
+// The expressions of the children are ordered by the first hostname they represent in natural
+// order, i.e., number tokens are compared by their integer values. A group of numbers sharing
+// the same suffix is placed at the position of its smallest number. The output is therefore
+// deterministic for the same set of hosts.
 func (n *TokenNode) GetExpression() string {
-	builder := strings.Builder{}
+	expr, _ := n.GetExpressionWithOptions(Options{})
+	return expr
+}
+
+// GetExpressionWithOptions returns a hostlist expression representing the TokenNode using
+// options opts, and a list of numbers which could not be joined into range expressions
+// because of their zero padding.
+func (n *TokenNode) GetExpressionWithOptions(opts Options) (string, []PaddingWarning) {
+	ctx := &expressionContext{Options: opts}
+	return n.getExpression(ctx), ctx.Warnings
+}
+
+// expressionContext keeps the states while generating a hostlist expression from TokenNode
+type expressionContext struct {
+	Options   Options
+	Warnings  []PaddingWarning
+	Conflicts []SuffixConflict
+	path      []string // Values of the tokens from the root to the current node
+}
+
+func (n *TokenNode) getExpression(ctx *expressionContext) string {
+	// Reuse the expression if the node has not been modified since the last call
+	if n.cache.Valid && n.cache.Options == ctx.Options {
+		ctx.Warnings = append(ctx.Warnings, n.cache.Warnings...)
+		ctx.Conflicts = append(ctx.Conflicts, n.cache.Conflicts...)
+		return n.cache.Expression
+	}
+	n.sortChildren()
+
+	// A leaf node is represented by its own token
+	if len(n.Children) == 0 && n.Token.Type != RootToken {
+		n.ChildredExpression = ""
+		n.cache = expressionCache{Valid: true, Options: ctx.Options, Expression: n.Token.Value}
+		return n.cache.Expression
+	}
+	warningStart := len(ctx.Warnings)
+	conflictStart := len(ctx.Conflicts)
 
 	if n.Token.Type != RootToken {
-		builder.WriteString(n.Token.Value)
+		ctx.path = append(ctx.path, n.Token.Value)
+		defer func() { ctx.path = ctx.path[:len(ctx.path)-1] }()
 	}
 
-	childExpressions := []string{}
+	childExpressions := make([]string, 0, len(n.Children)+1)
+
+	// A hostname ending at this node is represented by an empty expression, e.g., `host[,1]`
+	if n.Terminal && len(n.Children) > 0 {
+		childExpressions = append(childExpressions, "")
+	}
 
-	// A map of a list of number tokens with the same ChildrenExpression.
-	// The ChildrenExpression is used as key to group number token together for creating range expression
-	numberMaps := map[string][]*TokenNode{}
+	// Number tokens with the same ChildrenExpression are grouped together for creating range expression.
+	// The ChildrenExpression is used as key to find the group. Each group is placed at the position of
+	// its first number, so the expressions are ordered by their first hostname in natural order.
+	numberGroups := []numberGroup{}
+	groupIndex := map[string]int{}
 
 	for _, c := range n.Children {
 		if c.Token.Type == NumberToken {
-			c.GetExpression()
-			numberMaps[c.ChildredExpression] = append(numberMaps[c.ChildredExpression], c)
+			c.getExpression(ctx)
+			i, ok := groupIndex[c.ChildredExpression]
+			if !ok {
+				i = len(numberGroups)
+				groupIndex[c.ChildredExpression] = i
+				numberGroups = append(numberGroups, numberGroup{Suffix: c.ChildredExpression, Position: len(childExpressions)})
+				childExpressions = append(childExpressions, "") // Placeholder for the group expression
+			}
+			numberGroups[i].Nodes = append(numberGroups[i].Nodes, c)
 		} else {
-			childExpressions = append(childExpressions, c.GetExpression())
+			childExpressions = append(childExpressions, c.getExpression(ctx))
 		}
 	}
 
-	for suffix, numbers := range numberMaps {
+	for _, group := range numberGroups {
+		numbers, suffix := group.Nodes, group.Suffix
 		if len(numbers) == 1 {
-			childExpressions = append(childExpressions, fmt.Sprintf("%s%s", numbers[0].Token.Value, suffix))
+			childExpressions[group.Position] = numbers[0].Token.Value + suffix
 			continue
 		}
 
-		// List of number and range expressions
-		numberExpr := []string{}
-
-		// Sort TokenNodes based on its integer value
-		sort.Slice(numbers, func(i int, j int) bool {
-			return numbers[i].Token.Int < numbers[j].Token.Int
+		expr := rangeExpression(numberTokens(numbers), ctx.Options, func(lower, upper Token) {
+			ctx.Warnings = append(ctx.Warnings, PaddingWarning{
+				Prefix: strings.Join(ctx.path, ""),
+				Suffix: suffix,
+				Lower:  lower.Value,
+				Upper:  upper.Value,
+			})
 		})
+		if strings.Contains(expr, ",") || strings.Contains(expr, "-") {
+			expr = "[" + expr + "]"
+		}
+		childExpressions[group.Position] = expr + suffix
+	}
 
-		isRangeExpr := false
-		lb := numbers[0].Token.Value // lower bound of range expression
-		ub := ""                     // upper bound of range expression
-		for i := 1; i < len(numbers); i++ {
-			// Check if stride is 1 and both has the same zero padding length
-			if (numbers[i].Token.Int-numbers[i-1].Token.Int == 1) && numbers[i-1].Token.IsNext(numbers[i].Token) {
-				//(len(numbers[i].Token.Value) == len(numbers[i-1].Token.Value)) {
-				if !isRangeExpr { // Begin list of stride-1
-					isRangeExpr = true
-					lb = numbers[i-1].Token.Value
-					ub = numbers[i].Token.Value
-				} else { // Stride-1 continues, update the upper bound
-					ub = numbers[i].Token.Value
-				}
-			} else {
-				if isRangeExpr { // Stride-1 streak is broken. Append the current streak as range expression and start a new one.
-					numberExpr = append(numberExpr, fmt.Sprintf("%s-%s", lb, ub))
-					lb = numbers[i].Token.Value
-					isRangeExpr = false
-				} else { // There was no stride-1 streak. Just add the expression to children list
-					numberExpr = append(numberExpr, lb)
-					lb = numbers[i].Token.Value
-					isRangeExpr = false
-				}
-			}
+	// Number groups could not be merged into a single range expression because of their suffixes
+	if len(numberGroups) > 1 {
+		conflict := SuffixConflict{Prefix: strings.Join(ctx.path, "")}
+		for _, group := range numberGroups {
+			conflict.Expressions = append(conflict.Expressions, childExpressions[group.Position])
 		}
+		ctx.Conflicts = append(ctx.Conflicts, conflict)
+	}
 
-		// Add the last expression to the list
-		if isRangeExpr {
-			numberExpr = append(numberExpr, fmt.Sprintf("%s-%s", lb, ub))
-		} else {
-			numberExpr = append(numberExpr, lb)
+	// Compute the length of the expression to allocate the memory only once
+	size := len(childExpressions) + 1
+	for _, expr := range childExpressions {
+		size += len(expr)
+	}
+	bracket := len(childExpressions) > 1 && n.Token.Type != RootToken
+
+	builder := strings.Builder{}
+	builder.Grow(len(n.Token.Value) + size)
+	if n.Token.Type != RootToken {
+		builder.WriteString(n.Token.Value)
+	}
+	if bracket {
+		builder.WriteByte('[')
+	}
+	for i, expr := range childExpressions {
+		if i > 0 {
+			builder.WriteByte(',')
 		}
+		builder.WriteString(expr)
+	}
+	if bracket {
+		builder.WriteByte(']')
+	}
+
+	expression := builder.String()
+	if n.Token.Type != RootToken {
+		n.ChildredExpression = expression[len(n.Token.Value):]
+	} else {
+		n.ChildredExpression = expression
+	}
 
-		childExpressions = append(childExpressions, fmt.Sprintf("[%s]%s", strings.Join(numberExpr, ","), suffix))
+	n.cache = expressionCache{
+		Valid:      true,
+		Options:    ctx.Options,
+		Expression: expression,
+		Warnings:   slices.Clone(ctx.Warnings[warningStart:]),
+		Conflicts:  slices.Clone(ctx.Conflicts[conflictStart:]),
 	}
 
-	if len(childExpressions) == 1 {
-		builder.WriteString(childExpressions[0])
-		n.ChildredExpression = childExpressions[0]
-	} else if len(childExpressions) > 1 {
-		if n.Token.Type != RootToken {
-			builder.WriteString(fmt.Sprintf("[%s]", strings.Join(childExpressions, ",")))
-			n.ChildredExpression = fmt.Sprintf("[%s]", strings.Join(childExpressions, ","))
+	return n.cache.Expression
+}
+
+// invalidate discards the cached expression of the node
+func (n *TokenNode) invalidate() {
+	n.cache = expressionCache{}
+}
+
+// numberGroup represents number nodes sharing the same children expression
+type numberGroup struct {
+	Suffix   string       // Children expression shared by the nodes
+	Position int          // Position of the group in the list of child expressions
+	Nodes    []*TokenNode // Number nodes in natural order
+}
+
+// numberTokens returns the tokens of a list of TokenNode
+func numberTokens(nodes []*TokenNode) []Token {
+	tokens := make([]Token, len(nodes))
+	for i, n := range nodes {
+		tokens[i] = n.Token
+	}
+	return tokens
+}
+
+// normalizePadding returns number tokens padded to the width of the widest zero-padded
+// number. Numbers with the same integer value are removed.
+func normalizePadding(numbers []Token) []Token {
+	width := 0
+	for _, num := range numbers {
+		if num.ZeroPadded && len(num.Value) > width {
+			width = len(num.Value)
+		}
+	}
+
+	result := []Token{}
+	seen := map[string]bool{}
+	for _, num := range numbers {
+		value := utils.TrimZeros(num.Value)
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		result = append(result, NewToken(NumberToken, utils.PadZeros(value, width)))
+	}
+	return result
+}
+
+// rangeExpression returns the content of a range expression, without brackets, representing
+// a list of number tokens. Consecutive numbers with compatible zero padding are combined into
+// a range, e.g., `01,02,03,05` becomes `01-03,05`.
+//
+// If warn is not nil, it is called for every pair of adjacent numbers which are not combined
+// because of their zero padding.
+func rangeExpression(numbers []Token, opts Options, warn func(lower, upper Token)) string {
+	if len(numbers) == 0 {
+		return ""
+	}
+
+	if opts.Padding == PaddingNormalize {
+		numbers = normalizePadding(numbers)
+	}
+
+	// Sort tokens based on its integer value. Numbers from the children of a node are already sorted.
+	if !slices.IsSortedFunc(numbers, compareTokens) {
+		numbers = slices.Clone(numbers)
+		slices.SortFunc(numbers, compareTokens)
+	}
+
+	// List of number and range expressions
+	numberExpr := make([]string, 0, 1)
+
+	isRangeExpr := false
+	lb := numbers[0].Value // lower bound of range expression
+	ub := ""               // upper bound of range expression
+	for i := 1; i < len(numbers); i++ {
+		// Check if stride is 1 and both has the same zero padding length.
+		// A range starting with zero is expanded with the width of its longest bound,
+		// so the range must stop before the width changes, e.g., `09-10` but not `0-10`.
+		start := numbers[i-1].Value
+		if isRangeExpr {
+			start = lb
+		}
+		consecutive := numbers[i-1].IsNext(numbers[i])
+		next := consecutive && (start[0] != '0' || len(start) == len(numbers[i].Value))
+
+		if next {
+			if !isRangeExpr { // Begin list of stride-1
+				isRangeExpr = true
+				lb = numbers[i-1].Value
+				ub = numbers[i].Value
+			} else { // Stride-1 continues, update the upper bound
+				ub = numbers[i].Value
+			}
 		} else {
-			builder.WriteString(strings.Join(childExpressions, ","))
-			n.ChildredExpression = strings.Join(childExpressions, ",")
+			// Report adjacent numbers which are equal or consecutive but have different zero padding.
+			// A range split before the width changes, e.g., `[0-9,10]`, is not caused by zero padding.
+			if warn != nil && !consecutive && utils.CompareNumbers(numbers[i].Value, utils.IncrementNumber(numbers[i-1].Value)) <= 0 {
+				warn(numbers[i-1], numbers[i])
+			}
+
+			if isRangeExpr { // Stride-1 streak is broken. Append the current streak as range expression and start a new one.
+				numberExpr = append(numberExpr, lb+"-"+ub)
+			} else { // There was no stride-1 streak. Just add the expression to the list
+				numberExpr = append(numberExpr, lb)
+			}
+			lb = numbers[i].Value
+			isRangeExpr = false
 		}
 	}
 
-	return builder.String()
+	// Add the last expression to the list
+	if isRangeExpr {
+		numberExpr = append(numberExpr, lb+"-"+ub)
+	} else {
+		numberExpr = append(numberExpr, lb)
+	}
+
+	return strings.Join(numberExpr, ",")
 }
 
 // TokenPointer represents a pointer for traversing ExpressionTree
@@ -1,6 +1,7 @@
 package compress_test
 
 import (
+	"reflect"
 	"slices"
 	"strings"
 	"testing"
@@ -22,13 +23,13 @@ var PrintNodeTestcases = []CompressHostlistTestcase{
 	},
 	{
 		Hostlist:       []string{"aaaaa"},
-		ExpectedResult: `{R:a}->{R:a}->{R:a}->{R:a}->{R:a}`,
+		ExpectedResult: `{L:aaaaa}`,
 		ExpectedError:  nil,
 	},
 	{
 		Hostlist: []string{"aa", "ab"},
-		ExpectedResult: `{R:a}->{R:a}
-       {R:b}`,
+		ExpectedResult: `{L:a}->{L:a}
+       {L:b}`,
 		ExpectedError: nil,
 	},
 	{
@@ -41,67 +42,76 @@ var PrintNodeTestcases = []CompressHostlistTestcase{
 	},
 	{
 		Hostlist: []string{"192.168.1.1", "192.168.1.2", "192.168.1.120"},
-		ExpectedResult: `{D:192}->{R:.}->{D:168}->{R:.}->{D:1}->{R:.}->{D:1}
-                                              {D:120}
-                                              {D:2}`,
+		ExpectedResult: `{D:192}->{L:.}->{D:168}->{L:.}->{D:1}->{L:.}->{D:1}
+                                              {D:2}
+                                              {D:120}`,
 		ExpectedError: nil,
 	},
 	{
 		Hostlist: []string{"192.168.1.1", "192.168.1.2", "192.168.2.1", "192.168.2.2"},
-		ExpectedResult: `{D:192}->{R:.}->{D:168}->{R:.}->{D:1}->{R:.}->{D:1}
+		ExpectedResult: `{D:192}->{L:.}->{D:168}->{L:.}->{D:1}->{L:.}->{D:1}
                                               {D:2}
-                                {D:2}->{R:.}->{D:1}
+                                {D:2}->{L:.}->{D:1}
                                               {D:2}`,
 		ExpectedError: nil,
 	},
 
 	{
 		Hostlist: []string{"abcd", "abef", "abeg", "xyz", "x1z", "x2z"},
-		ExpectedResult: `{R:a}->{R:b}->{R:c}->{R:d}
-              {R:e}->{R:f}
-                     {R:g}
-{R:x}->{D:1}->{R:z}
-       {D:2}->{R:z}
-       {R:y}->{R:z}`,
+		ExpectedResult: `{L:ab}->{L:cd}
+        {L:e}->{L:f}
+               {L:g}
+{L:x}->{D:1}->{L:z}
+       {D:2}->{L:z}
+       {L:yz}`,
 		ExpectedError: nil,
 	},
 	{
 		Hostlist: []string{"host-01", "a", "b", "host-03", "host-02", "10-host-120", "11-host-120", "zz-01-a", "yz-01-b", "yz-02-v", "yz-02x"},
-		ExpectedResult: `{D:10}->{R:-}->{R:h}->{R:o}->{R:s}->{R:t}->{R:-}->{D:120}
-{D:11}->{R:-}->{R:h}->{R:o}->{R:s}->{R:t}->{R:-}->{D:120}
-{R:a}
-{R:b}
-{R:h}->{R:o}->{R:s}->{R:t}->{R:-}->{D:01}
-                                   {D:02}
-                                   {D:03}
-{R:y}->{R:z}->{R:-}->{D:01}->{R:-}->{R:b}
-                     {D:02}->{R:-}->{R:v}
-                             {R:x}
-{R:z}->{R:z}->{R:-}->{D:01}->{R:-}->{R:a}`,
+		ExpectedResult: `{D:10}->{L:-host-}->{D:120}
+{D:11}->{L:-host-}->{D:120}
+{L:a}
+{L:b}
+{L:host-}->{D:01}
+           {D:02}
+           {D:03}
+{L:yz-}->{D:01}->{L:-b}
+         {D:02}->{L:-v}
+                 {L:x}
+{L:zz-}->{D:01}->{L:-a}`,
 		ExpectedError: nil,
 	},
 }
 
 // TestPrintNode tests TokenNode.PrintNode by creating a HostlistExpressionTree without compressing
-// and check for return value
+// and check for return value. The hosts are added in the given order and in reverse order,
+// which must result in the same tree.
 func TestPrintNode(t *testing.T) {
 	for _, c := range PrintNodeTestcases {
 		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
-		slices.Sort(c.Hostlist)
 
-		tree := compress.NewHostlistExpressionTree()
-		for _, h := range c.Hostlist {
-			tree.AddHost(h)
-		}
+		for _, hosts := range [][]string{c.Hostlist, reversed(c.Hostlist)} {
+			tree := compress.NewHostlistExpressionTree()
+			for _, h := range hosts {
+				tree.AddHost(h)
+			}
 
-		result := strings.TrimSpace(tree.Root.PrintNode())
+			result := strings.TrimSpace(tree.Root.PrintNode())
 
-		if result != c.ExpectedResult {
-			t.Fatalf("Invalid tree: actual:\n%x\nexpect:\n%x\n", result, c.ExpectedResult)
+			if result != c.ExpectedResult {
+				t.Fatalf("Invalid tree: actual:\n%x\nexpect:\n%x\n", result, c.ExpectedResult)
+			}
 		}
 	}
 }
 
+// reversed returns a reversed copy of hosts
+func reversed(hosts []string) []string {
+	r := slices.Clone(hosts)
+	slices.Reverse(r)
+	return r
+}
+
 var GetExpressionTestcases = []CompressHostlistTestcase{
 	{
 		Hostlist:       []string{},
@@ -118,6 +128,11 @@ var GetExpressionTestcases = []CompressHostlistTestcase{
 		ExpectedResult: "a[a,b]",
 		ExpectedError:  nil,
 	},
+	{
+		Hostlist:       []string{"a\xc3\xa9\xa9", "a\xc3\xa9x"},
+		ExpectedResult: "a\xc3\xa9[x,\xa9]",
+		ExpectedError:  nil,
+	},
 	{
 		Hostlist:       []string{"7", "8", "9", "10", "11"},
 		ExpectedResult: "[7-11]",
@@ -163,33 +178,133 @@ var GetExpressionTestcases = []CompressHostlistTestcase{
 		ExpectedResult: "[1-2].0.[3-4]",
 		ExpectedError:  nil,
 	},
+	{
+		Hostlist:       []string{"4b", "3a", "2b", "1a", "5c", "10-c"},
+		ExpectedResult: "[1,3]a,[2,4]b,5c,10-c",
+		ExpectedError:  nil,
+	},
+	{
+		Hostlist:       []string{"node000123456789012345679", "node000123456789012345678", "node000123456789012345680"},
+		ExpectedResult: "node[000123456789012345678-000123456789012345680]",
+		ExpectedError:  nil,
+	},
+	{
+		Hostlist:       []string{"n1", "n0", "n99999999999999999999", "n100000000000000000000"},
+		ExpectedResult: "n[0-1,99999999999999999999-100000000000000000000]",
+		ExpectedError:  nil,
+	},
+	{
+		Hostlist:       []string{"nœud1", "nœud2", "nœud3", "nöd1", ""},
+		ExpectedResult: "n[öd1,œud[1-3]]",
+		ExpectedError:  nil,
+	},
 	{
 		Hostlist:       []string{"abcd", "abef", "abeg", "xyz", "x1z", "x2z"},
-		ExpectedResult: "ab[cd,e[f,g]],x[yz,[1-2]z]",
+		ExpectedResult: "ab[cd,e[f,g]],x[[1-2]z,yz]",
 		ExpectedError:  nil,
 	},
 	{
 		Hostlist:       []string{"host-01", "a", "b", "host-03", "host-02", "10-host-120", "11-host-120", "zz-01-a", "yz-01-b", "yz-02-v", "yz-02x"},
-		ExpectedResult: "a,b,host-[01-03],yz-[01-b,02[-v,x]],zz-01-a,[10-11]-host-120",
+		ExpectedResult: "[10-11]-host-120,a,b,host-[01-03],yz-[01-b,02[-v,x]],zz-01-a",
 		ExpectedError:  nil,
 	},
 }
 
-// TestGetExpression tests TokenNode.GetExpression
+// TestGetExpression tests TokenNode.GetExpression. The hosts are added in the given order
+// and in reverse order, which must result in the same expression.
 func TestGetExpression(t *testing.T) {
 	for _, c := range GetExpressionTestcases {
 		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
-		slices.Sort(c.Hostlist)
+
+		for _, hosts := range [][]string{c.Hostlist, reversed(c.Hostlist)} {
+			tree := compress.NewHostlistExpressionTree()
+			for _, h := range hosts {
+				tree.AddHost(h)
+			}
+
+			result := tree.Root.GetExpression()
+
+			if result != c.ExpectedResult {
+				t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", result, c.ExpectedResult)
+			}
+		}
+	}
+}
+
+type GetExpressionWithOptionsTestcase struct {
+	Hostlist         []string
+	Options          compress.Options
+	ExpectedResult   string
+	ExpectedWarnings []compress.PaddingWarning
+}
+
+var GetExpressionWithOptionsTestcases = []GetExpressionWithOptionsTestcase{
+	{
+		Hostlist:         []string{"a7", "a8", "a9", "a10", "a11"},
+		Options:          compress.Options{},
+		ExpectedResult:   "a[7-11]",
+		ExpectedWarnings: nil,
+	},
+	{
+		Hostlist:       []string{"99b", "98b", "100b", "0101b"},
+		Options:        compress.Options{},
+		ExpectedResult: "[98-100,0101]b",
+		ExpectedWarnings: []compress.PaddingWarning{
+			{Prefix: "", Suffix: "b", Lower: "100", Upper: "0101"},
+		},
+	},
+	{
+		Hostlist:         []string{"n0", "n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10"},
+		Options:          compress.Options{},
+		ExpectedResult:   "n[0-9,10]",
+		ExpectedWarnings: nil,
+	},
+	{
+		Hostlist:       []string{"node1", "node001", "node2"},
+		Options:        compress.Options{Padding: compress.PaddingStrict},
+		ExpectedResult: "node[001,1-2]",
+		ExpectedWarnings: []compress.PaddingWarning{
+			{Prefix: "node", Suffix: "", Lower: "001", Upper: "1"},
+		},
+	},
+	{
+		Hostlist:         []string{"node1", "node001", "node2"},
+		Options:          compress.Options{Padding: compress.PaddingNormalize},
+		ExpectedResult:   "node[001-002]",
+		ExpectedWarnings: nil,
+	},
+	{
+		Hostlist:         []string{"99b", "98b", "100b", "0101b"},
+		Options:          compress.Options{Padding: compress.PaddingNormalize},
+		ExpectedResult:   "[0098-0101]b",
+		ExpectedWarnings: nil,
+	},
+	{
+		Hostlist:         []string{"node1", "node01"},
+		Options:          compress.Options{Padding: compress.PaddingNormalize},
+		ExpectedResult:   "node01",
+		ExpectedWarnings: nil,
+	},
+}
+
+// TestGetExpressionWithOptions tests HostlistExpressionTree.GetExpressionWithWarnings
+func TestGetExpressionWithOptions(t *testing.T) {
+	for _, c := range GetExpressionWithOptionsTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
 
 		tree := compress.NewHostlistExpressionTree()
+		tree.Options = c.Options
 		for _, h := range c.Hostlist {
 			tree.AddHost(h)
 		}
 
-		result := tree.Root.GetExpression()
+		result, warnings := tree.GetExpressionWithWarnings()
 
 		if result != c.ExpectedResult {
 			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", result, c.ExpectedResult)
 		}
+		if !reflect.DeepEqual(warnings, c.ExpectedWarnings) {
+			t.Fatalf("Invalid warnings: actual: %+v expect: %+v", warnings, c.ExpectedWarnings)
+		}
 	}
 }
@@ -0,0 +1,38 @@
+package compress
+
+import (
+	"fmt"
+)
+
+// PaddingMode controls how numbers with different zero padding are compressed
+type PaddingMode int
+
+const (
+	// PaddingStrict treats numbers with different zero padding as different values, e.g.,
+	// `node1` and `node001` are different hosts. Consecutive numbers with incompatible
+	// zero padding are not joined into a range.
+	PaddingStrict PaddingMode = iota
+	// PaddingNormalize treats numbers with different zero padding as the same value, e.g.,
+	// `node1` and `node001` are the same host. Numbers in the same range expression are
+	// padded to the width of the widest zero-padded number (Slurm-style), e.g.,
+	// `node1`, `node002`, `node3` becomes `node[001-003]`.
+	PaddingNormalize
+)
+
+// Options controls the hostlist expression generated from an expression tree.
+type Options struct {
+	Padding PaddingMode
+}
+
+// PaddingWarning reports two numbers which could not be joined into a range expression
+// because of their zero padding.
+type PaddingWarning struct {
+	Prefix string // Expression before the range expression
+	Suffix string // Expression after the range expression
+	Lower  string // Lower number
+	Upper  string // Upper number
+}
+
+func (w PaddingWarning) String() string {
+	return fmt.Sprintf("%s[%s,%s]%s: cannot join numbers with different zero padding", w.Prefix, w.Lower, w.Upper, w.Suffix)
+}
@@ -0,0 +1,47 @@
+package compress
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SuffixConflict reports number groups sharing the same prefix which could not be merged into
+// a single range expression because they are followed by different expressions.
+type SuffixConflict struct {
+	Prefix      string   // Expression before the number groups
+	Expressions []string // Expressions of the number groups, including their suffixes
+}
+
+func (c SuffixConflict) String() string {
+	return fmt.Sprintf("%s{%s}: cannot merge numbers with different suffixes", c.Prefix, strings.Join(c.Expressions, ","))
+}
+
+// Report contains diagnostics about how a hostlist expression was generated
+type Report struct {
+	PaddingWarnings  []PaddingWarning // Ranges split because of different zero padding
+	SuffixConflicts  []SuffixConflict // Number groups not merged because of different suffixes
+	Duplicates       []string         // Hostnames appearing more than once in the input, in input order
+	Hosts            int              // Number of unique hostnames
+	InputLength      int              // Length of unique hostnames separated by ','
+	OutputLength     int              // Length of the hostlist expression
+	CompressionRatio float64          // InputLength / OutputLength. 0 if the expression is empty
+}
+
+func (r Report) String() string {
+	builder := strings.Builder{}
+	builder.WriteString(fmt.Sprintf("hosts: %d\n", r.Hosts))
+	builder.WriteString(fmt.Sprintf("duplicates removed: %d\n", len(r.Duplicates)))
+	for _, d := range r.Duplicates {
+		builder.WriteString("  " + d + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("compression ratio: %.2f (%d -> %d characters)\n", r.CompressionRatio, r.InputLength, r.OutputLength))
+	builder.WriteString(fmt.Sprintf("ranges split by zero padding: %d\n", len(r.PaddingWarnings)))
+	for _, w := range r.PaddingWarnings {
+		builder.WriteString("  " + w.String() + "\n")
+	}
+	builder.WriteString(fmt.Sprintf("groups split by suffix: %d\n", len(r.SuffixConflicts)))
+	for _, c := range r.SuffixConflicts {
+		builder.WriteString("  " + c.String() + "\n")
+	}
+	return builder.String()
+}
@@ -4,15 +4,18 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
-	"unicode"
+	"unicode/utf8"
+
+	"github.com/puttsk/hostlist/utils"
 )
 
 type TokenType int16
 
 const (
-	RootToken   TokenType = iota // Root node
-	RuneToken                    // Token containing a single character
-	NumberToken                  // Token containing a number, including leading zeroes
+	RootToken    TokenType = iota // Root node
+	RuneToken                     // Token containing a single character
+	NumberToken                   // Token containing a number, including leading zeroes
+	LiteralToken                  // Token containing a run of non-digit characters
 )
 
 func (t TokenType) String() string {
@@ -23,14 +26,19 @@ func (t TokenType) String() string {
 		return "R"
 	case NumberToken:
 		return "D"
+	case LiteralToken:
+		return "L"
 	}
 	return "unknown"
 }
 
+// maxIntDigits is the number of digits that always fits in int
+const maxIntDigits = strconv.IntSize*3/10 - 1
+
 type Token struct {
 	Value      string
 	Type       TokenType
-	Int        int  // Integer value. For NumberToken only
+	Int        int  // Integer value if it fits in int, otherwise 0. For NumberToken only. Numbers are compared using Value
 	ZeroPadded bool // True if the integer is zero padded. For NumberToken only
 }
 
@@ -38,8 +46,9 @@ func (t Token) String() string {
 	return fmt.Sprintf("{%s:%s}", t.Type, t.Value)
 }
 
-// IsNext returns true of Token a is a continuation of Token t, i.e., the Int value of
+// IsNext returns true of Token a is a continuation of Token t, i.e., the value of
 // Token a is greater than Token t by 1 and both have the same zeroes padding.
+// Numbers of arbitrary length are supported.
 func (t Token) IsNext(a Token) bool {
 	if t.Type != NumberToken {
 		return false
@@ -49,8 +58,12 @@ func (t Token) IsNext(a Token) bool {
 		return false
 	}
 
-	// Check if a-t == 1
-	if a.Int-t.Int != 1 {
+	// Check if a-t == 1. Int is used if both numbers fit in int
+	if len(t.Value) <= maxIntDigits && len(a.Value) <= maxIntDigits {
+		if t.Int+1 != a.Int {
+			return false
+		}
+	} else if utils.CompareNumbers(utils.IncrementNumber(t.Value), a.Value) != 0 {
 		return false
 	}
 
@@ -67,6 +80,18 @@ func (t Token) IsNext(a Token) bool {
 	return true
 }
 
+// compareTokens orders tokens for sorting. Number tokens are compared by their integer value
+// first, and the string value is used to break ties between differently padded numbers.
+// Other tokens are compared by their string value.
+func compareTokens(a, b Token) int {
+	if a.Type == NumberToken && b.Type == NumberToken {
+		if c := utils.CompareNumbers(a.Value, b.Value); c != 0 {
+			return c
+		}
+	}
+	return strings.Compare(a.Value, b.Value)
+}
+
 // NewToken initializes a Token of type `t` with the providing `args`
 func NewToken(t TokenType, args ...string) Token {
 	tok := Token{Type: t}
@@ -74,58 +99,104 @@ func NewToken(t TokenType, args ...string) Token {
 	case RootToken:
 		tok.Value = "*"
 	case RuneToken:
-		tok.Value = args[0][:1] // Keep only the first character of the first string
+		// Keep only the first character of the first string
+		_, size := utf8.DecodeRuneInString(args[0])
+		tok.Value = args[0][:size]
+	case LiteralToken:
+		tok.Value = args[0]
 	case NumberToken:
 		tok.Value = args[0]
-		v, _ := strconv.ParseInt(args[0], 10, 0)
-		tok.Int = int(v)
-		if args[0][0] == '0' {
+		// Int is left as 0 if the number does not fit in int
+		if v, err := strconv.ParseInt(args[0], 10, 0); err == nil {
+			tok.Int = int(v)
+		}
+		if len(args[0]) > 0 && args[0][0] == '0' {
 			tok.ZeroPadded = true
 		}
 	}
 	return tok
 }
 
+// isDigit returns true if r is an ASCII digit. Other Unicode digits are not part of number
+// tokens, which is consistent with the digits accepted by package expand.
+func isDigit(r rune) bool {
+	return r >= '0' && r <= '9'
+}
+
 // Tokenize converts string to a list of tokens for hostlist expression.
 // Token can be either a rune token, containing single character, or
 // a number token, containing an integer.
+//
+// Tokenize is rune-aware: a multi-byte character becomes a single rune token, and an invalid
+// UTF-8 byte becomes a rune token containing that byte. Only ASCII digits form number tokens.
+// An empty string returns an empty list.
 func Tokenize(str string) []Token {
 	result := []Token{}
-	hasDigit := false
-	builder := strings.Builder{}
-	builder.WriteByte(str[0])
-	if unicode.IsDigit(rune(str[0])) {
-		hasDigit = true
-	}
+	numberStart := -1 // Start of the current number token, or -1 if not in a number
 
-	for _, s := range str[1:] {
-		if !unicode.IsDigit(s) {
-			tok := RuneToken
-			if hasDigit {
-				tok = NumberToken
+	for i := 0; i < len(str); {
+		r, size := utf8.DecodeRuneInString(str[i:])
+		if isDigit(r) {
+			if numberStart < 0 {
+				numberStart = i
 			}
-			result = append(result, NewToken(tok, builder.String()))
-			hasDigit = false
-			builder.Reset()
 		} else {
-			if !hasDigit {
-				tok := RuneToken
-				if hasDigit {
-					tok = NumberToken
-				}
-				result = append(result, NewToken(tok, builder.String()))
-				hasDigit = true
-				builder.Reset()
+			if numberStart >= 0 {
+				result = append(result, NewToken(NumberToken, str[numberStart:i]))
+				numberStart = -1
 			}
+			result = append(result, NewToken(RuneToken, str[i:i+size]))
 		}
-		builder.WriteRune(s)
+		i += size
 	}
-	if builder.Len() > 0 {
-		tok := RuneToken
-		if hasDigit {
-			tok = NumberToken
+
+	if numberStart >= 0 {
+		result = append(result, NewToken(NumberToken, str[numberStart:]))
+	}
+	return result
+}
+
+// tokenizeLiterals converts string to a list of tokens for the expression tree. Unlike Tokenize,
+// a run of non-digit characters becomes a single literal token. The values of the tokens
+// share the memory of str.
+//
+// For example:
+//
+//	`host01` will be converted to `{L:host}{D:01}`
+func tokenizeLiterals(str string) []Token {
+	result := make([]Token, 0, 4)
+	for i := 0; i < len(str); {
+		j := i
+		if isDigit(rune(str[i])) {
+			for j < len(str) && isDigit(rune(str[j])) {
+				j++
+			}
+			result = append(result, NewToken(NumberToken, str[i:j]))
+		} else {
+			// Digits are ASCII, so a multi-byte character is never split
+			for j < len(str) && !isDigit(rune(str[j])) {
+				j++
+			}
+			result = append(result, NewToken(LiteralToken, str[i:j]))
 		}
-		result = append(result, NewToken(tok, builder.String()))
+		i = j
 	}
 	return result
 }
+
+// commonPrefix returns the length in bytes of the longest common prefix of a and b,
+// which ends at a character boundary. The strings are compared character by character,
+// so an invalid UTF-8 byte is a single character, as in Tokenize, and two strings starting
+// with the same character always have a common prefix of at least that character.
+func commonPrefix(a, b string) int {
+	p := 0
+	for p < len(a) && p < len(b) {
+		_, aSize := utf8.DecodeRuneInString(a[p:])
+		_, bSize := utf8.DecodeRuneInString(b[p:])
+		if aSize != bSize || a[p:p+aSize] != b[p:p+bSize] {
+			break
+		}
+		p += aSize
+	}
+	return p
+}
@@ -0,0 +1,73 @@
+package compress_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/puttsk/hostlist/compress"
+)
+
+type TokenizeTestcase struct {
+	Input          string
+	ExpectedResult []compress.Token
+}
+
+var TokenizeTestcases = []TokenizeTestcase{
+	{
+		Input:          "",
+		ExpectedResult: []compress.Token{},
+	},
+	{
+		Input: "a1",
+		ExpectedResult: []compress.Token{
+			{Value: "a", Type: compress.RuneToken},
+			{Value: "1", Type: compress.NumberToken, Int: 1},
+		},
+	},
+	{
+		Input: "01-b",
+		ExpectedResult: []compress.Token{
+			{Value: "01", Type: compress.NumberToken, Int: 1, ZeroPadded: true},
+			{Value: "-", Type: compress.RuneToken},
+			{Value: "b", Type: compress.RuneToken},
+		},
+	},
+	{
+		Input: "nœud12",
+		ExpectedResult: []compress.Token{
+			{Value: "n", Type: compress.RuneToken},
+			{Value: "œ", Type: compress.RuneToken},
+			{Value: "u", Type: compress.RuneToken},
+			{Value: "d", Type: compress.RuneToken},
+			{Value: "12", Type: compress.NumberToken, Int: 12},
+		},
+	},
+	{
+		// Non-ASCII digits are not numbers
+		Input: "n٣4",
+		ExpectedResult: []compress.Token{
+			{Value: "n", Type: compress.RuneToken},
+			{Value: "٣", Type: compress.RuneToken},
+			{Value: "4", Type: compress.NumberToken, Int: 4},
+		},
+	},
+	{
+		// Invalid UTF-8 byte is kept as is
+		Input: "\xff7",
+		ExpectedResult: []compress.Token{
+			{Value: "\xff", Type: compress.RuneToken},
+			{Value: "7", Type: compress.NumberToken, Int: 7},
+		},
+	},
+}
+
+// TestTokenize tests compress.Tokenize
+func TestTokenize(t *testing.T) {
+	for _, c := range TokenizeTestcases {
+		t.Logf("Testcase: %q\n", c.Input)
+		result := compress.Tokenize(c.Input)
+		if !reflect.DeepEqual(result, c.ExpectedResult) {
+			t.Fatalf("Invalid tokens: actual: %+v expect: %+v", result, c.ExpectedResult)
+		}
+	}
+}
@@ -10,6 +10,7 @@ var ErrNestedRangeExpression = errors.New("range expression cannot be nested")
 var ErrExpectedCloseBracket = errors.New("cannot find matching ']'")
 var ErrNotSingleExpression = errors.New("more than single expression detected")
 var ErrInvalidRange = errors.New("end value must be greater than start")
+var ErrTooManyHosts = errors.New("expression expands to too many hostnames")
 
 type ErrInvalidToken struct {
 	Token    rune
@@ -1,9 +1,7 @@
 package expand
 
 import (
-	"fmt"
 	"regexp"
-	"strconv"
 	"strings"
 
 	"github.com/puttsk/hostlist/utils"
@@ -70,7 +68,8 @@ func SplitExpressions(hostlist string) ([]string, error) {
 
 var rangeExprRegex = regexp.MustCompile(`^(?P<start>\d+)\-(?P<end>\d+)$`)
 
-// ExpandRangeExpression expand a range expression and return an array of hostnames of that expression
+// ExpandRangeExpression expand a range expression and return an array of hostnames of that expression.
+// Numbers in a range can be longer than 64 bits.
 //
 // For example:
 //
@@ -98,21 +97,17 @@ func ExpandRangeExpression(expression string) ([]string, error) {
 				leadingZeroes = max(len(start), len(end))
 			}
 
-			s, err := strconv.ParseInt(start, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-			e, err := strconv.ParseInt(end, 10, 64)
-			if err != nil {
-				return nil, err
-			}
-			if e < s {
+			// Numbers are compared and incremented as strings to support numbers longer than 64 bits
+			if utils.CompareNumbers(end, start) < 0 {
 				return nil, ErrInvalidRange
 			}
 
-			rangeFormat := fmt.Sprintf("%%0%dd", leadingZeroes)
-			for i := s; i <= e; i++ {
-				rangeList = append(rangeList, fmt.Sprintf(rangeFormat, i))
+			last := utils.TrimZeros(end)
+			for i := utils.TrimZeros(start); ; i = utils.IncrementNumber(i) {
+				rangeList = append(rangeList, utils.PadZeros(i, leadingZeroes))
+				if i == last {
+					break
+				}
 			}
 		}
 	}
@@ -126,85 +121,18 @@ func ExpandRangeExpression(expression string) ([]string, error) {
 //	`host-[001-003]` will be converted to `["host-001", "host-002", "host-003"]`
 //	`host-1,host-2` will return ErrNotSingleExpression
 func ExpandSingleExpression(expression string) ([]string, error) {
-	if expression == "" {
-		return nil, ErrEmptyExpression
+	p, err := ParsePattern(expression)
+	if err != nil {
+		return nil, err
 	}
-
-	hosts := []string{}
-	rangeExpr := []string{}
-
-	bracket := 0 // For check bracket level
-	var prefixBuilder strings.Builder
-	var rangeBuilder strings.Builder
-
-	// Collect and check hostlist expressions
-	for i, s := range expression {
-		if !(IsValidRune(s)) {
-			return nil, ErrInvalidToken{s, i + 1}
-		}
-
-		// Detect another hostlist expression
-		if s == ',' && bracket == 0 {
-			return nil, ErrNotSingleExpression
-		}
-
-		// Check bracket for range expression
-		if s == '[' {
-			// Check if this is nested ranged.
-			if bracket > 0 {
-				return nil, ErrNestedRangeExpression
-			}
-			bracket = bracket + 1 // Increase bracket level
-			continue
-		} else if s == ']' {
-			// Found ']' without matching bracket
-			if bracket == 0 {
-				return nil, ErrInvalidToken{']', i + 1}
-			}
-			bracket = bracket - 1 // Decrease bracket level
-
-			// Range expression is closed, collect range expression
-			if bracket == 0 {
-				rangeExpr = append(rangeExpr, rangeBuilder.String())
-				rangeBuilder.Reset()
-				prefixBuilder.WriteString("%s")
-				continue
-			}
-		}
-		if bracket == 0 {
-			prefixBuilder.WriteRune(s)
-		} else {
-			rangeBuilder.WriteRune(s)
-		}
-	}
-
-	// Check if all brackets are closed
-	if bracket > 0 {
-		return nil, ErrExpectedCloseBracket
-	}
-
-	if len(rangeExpr) > 0 {
-		rList := [][]interface{}{}
-		for _, expr := range rangeExpr {
-			r, err := ExpandRangeExpression(expr)
-			if err != nil {
-				return nil, err
-			}
-			p := make([]interface{}, len(r))
-			for i := range r {
-				p[i] = r[i]
-			}
-			rList = append(rList, p)
-		}
-
-		hostFormat := prefixBuilder.String()
-		rProduct := utils.CartesianProduct(rList)
-		for _, r := range rProduct {
-			hosts = append(hosts, fmt.Sprintf(hostFormat, r...))
-		}
-	} else {
-		hosts = append(hosts, prefixBuilder.String())
+	if p.Len() < 0 {
+		return nil, ErrTooManyHosts
 	}
 
+	hosts := make([]string, 0, p.Len())
+	p.ForEach(func(host []byte) bool {
+		hosts = append(hosts, string(host))
+		return true
+	})
 	return hosts, nil
 }
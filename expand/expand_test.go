@@ -54,6 +54,21 @@ var ExpandRangeExpressionTestcases = []ExpandHostlistTestcase{
 		ExpectedResult:     []string{"009", "010", "011", " ", "013", "0099", "0100"},
 		ExpectedError:      nil,
 	},
+	{
+		HostlistExpression: "18446744073709551615-18446744073709551617",
+		ExpectedResult:     []string{"18446744073709551615", "18446744073709551616", "18446744073709551617"},
+		ExpectedError:      nil,
+	},
+	{
+		HostlistExpression: "000123456789012345678998-000123456789012345679001",
+		ExpectedResult:     []string{"000123456789012345678998", "000123456789012345678999", "000123456789012345679000", "000123456789012345679001"},
+		ExpectedError:      nil,
+	},
+	{
+		HostlistExpression: "123456789012345678901-123456789012345678900",
+		ExpectedResult:     nil,
+		ExpectedError:      expand.ErrInvalidRange,
+	},
 	{
 		HostlistExpression: "100-10",
 		ExpectedResult:     nil,
@@ -168,3 +183,79 @@ func TestExpandSingleExpression(t *testing.T) {
 		}
 	}
 }
+
+// TestParsePattern calls expand.ParsePattern with hostlist expression, checking that
+// the hostnames generated by the pattern are the same as expand.ExpandSingleExpression.
+func TestParsePattern(t *testing.T) {
+	for _, c := range ExpandSingleExpressionTestcases {
+		t.Logf("Testcase: %s\n", c.HostlistExpression)
+		p, err := expand.ParsePattern(c.HostlistExpression)
+		if err != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %s expected: %s", err, c.ExpectedError)
+		}
+		if err != nil {
+			continue
+		}
+		if p.Len() != len(c.ExpectedResult) {
+			t.Fatalf("Invalid length: actual: %d expect: %d", p.Len(), len(c.ExpectedResult))
+		}
+
+		hostnames := []string{}
+		p.ForEach(func(host []byte) bool {
+			hostnames = append(hostnames, string(host))
+			return true
+		})
+		if !reflect.DeepEqual(hostnames, c.ExpectedResult) {
+			t.Fatalf("Invalid hostnames: actual: %+v expect: %+v", hostnames, c.ExpectedResult)
+		}
+
+		for i, expected := range c.ExpectedResult {
+			if host := string(p.AppendHost(nil, i)); host != expected {
+				t.Fatalf("Invalid hostname %d: actual: %s expect: %s", i, host, expected)
+			}
+		}
+	}
+}
+
+// TestParsePatternTooManyHosts checks that expand.ExpandSingleExpression rejects expressions whose
+// number of hostnames does not fit in int, and that their patterns can be parsed
+func TestParsePatternTooManyHosts(t *testing.T) {
+	for _, expr := range []string{
+		"h[0-99999999999999999999]",
+		"h[0-9999999999][0-9999999999]",
+		"h[100000000000000000000-200000000000000000000]",
+	} {
+		t.Logf("Testcase: %s\n", expr)
+		if _, err := expand.ExpandSingleExpression(expr); err != expand.ErrTooManyHosts {
+			t.Fatalf("Invalid error: actual: %s expected: %s", err, expand.ErrTooManyHosts)
+		}
+		p, err := expand.ParsePattern(expr)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if p.Len() != -1 {
+			t.Fatalf("Invalid length: actual: %d expect: %d", p.Len(), -1)
+		}
+
+		// A pattern with too many hostnames cannot be iterated
+		if !p.ForEach(func(host []byte) bool {
+			t.Fatalf("Invalid hostname: %s", host)
+			return true
+		}) {
+			t.Fatalf("Invalid ForEach result: actual: %t expect: %t", false, true)
+		}
+		p.ForEachRange(0, 10, func(host []byte) bool {
+			t.Fatalf("Invalid hostname: %s", host)
+			return true
+		})
+		if index := p.RangeIndex(0, 0); index != -1 {
+			t.Fatalf("Invalid range index: actual: %d expect: %d", index, -1)
+		}
+		if host := p.AppendHost(nil, 0); len(host) != 0 {
+			t.Fatalf("Invalid hostname: actual: %s expect: %s", host, "")
+		}
+		if value := p.AppendRangeValue(nil, 0, 0); len(value) != 0 {
+			t.Fatalf("Invalid range value: actual: %s expect: %s", value, "")
+		}
+	}
+}
@@ -0,0 +1,501 @@
+package expand
+
+import (
+	"math"
+	"math/big"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/puttsk/hostlist/utils"
+)
+
+// maxUintDigits is the number of digits that always fits in uint64
+const maxUintDigits = 19
+
+// Pattern represents a parsed single hostlist expression. A Pattern generates its hostnames
+// directly into a byte buffer, without creating a list of range elements.
+type Pattern struct {
+	parts  []patternPart
+	ranges []int // Indexes of the range expressions in parts
+	count  int   // Number of hostnames, or tooMany
+
+	indexOnce sync.Once
+	index     []rangeIndex // Index of the range expressions for Match. Built on the first call
+}
+
+// tooMany is the number of values of a pattern, a part, or an element which does not fit in int
+const tooMany = -1
+
+// patternPart represents either a literal part or a range expression of a Pattern
+type patternPart struct {
+	Literal  string         // Value of a literal part
+	Elements []rangeElement // Elements of a range expression. nil for a literal part
+	Count    int            // Number of values in the part
+	Stride   int            // Number of hostnames generated before the value of the part changes
+}
+
+// rangeElement represents a single value or a numeric range in a range expression
+type rangeElement struct {
+	Value  string   // Single value. Used if Count is 1 and Start is nil
+	Small  uint64   // Start of a range whose end fits in uint64
+	Start  *big.Int // Start of a range whose end does not fit in uint64
+	Low    string   // Lower bound of the range without leading zeroes
+	High   string   // Upper bound of the range without leading zeroes
+	Width  int      // Zero padding width of the range
+	Count  int      // Number of values in the element
+	Offset int      // Index of the first value of the element in the part
+	IsNum  bool     // True if the element is a numeric range
+}
+
+// ParsePattern parses a single hostlist expression. ParsePattern returns the same errors as
+// ExpandSingleExpression, except ErrTooManyHosts: a pattern with more hostnames than fits in int
+// can be matched against hostnames, but not expanded.
+//
+// For example:
+//
+//	`host-[001-003]` is parsed into the literal `host-` and the range `001-003`
+func ParsePattern(expression string) (*Pattern, error) {
+	if expression == "" {
+		return nil, ErrEmptyExpression
+	}
+
+	p := &Pattern{count: 1}
+
+	bracket := 0 // For check bracket level
+	partStart := 0
+
+	// Collect and check hostlist expressions
+	for i, s := range expression {
+		if !(IsValidRune(s)) {
+			return nil, ErrInvalidToken{s, i + 1}
+		}
+
+		// Detect another hostlist expression
+		if s == ',' && bracket == 0 {
+			return nil, ErrNotSingleExpression
+		}
+
+		// Check bracket for range expression
+		if s == '[' {
+			// Check if this is nested ranged.
+			if bracket > 0 {
+				return nil, ErrNestedRangeExpression
+			}
+			bracket = bracket + 1 // Increase bracket level
+			if partStart < i {
+				p.parts = append(p.parts, patternPart{Literal: expression[partStart:i], Count: 1})
+			}
+			partStart = i + 1
+		} else if s == ']' {
+			// Found ']' without matching bracket
+			if bracket == 0 {
+				return nil, ErrInvalidToken{']', i + 1}
+			}
+			bracket = bracket - 1 // Decrease bracket level
+
+			// Range expression is closed, collect range expression
+			p.ranges = append(p.ranges, len(p.parts))
+			p.parts = append(p.parts, patternPart{Literal: expression[partStart:i]})
+			partStart = i + 1
+		}
+	}
+
+	// Check if all brackets are closed
+	if bracket > 0 {
+		return nil, ErrExpectedCloseBracket
+	}
+	if partStart < len(expression) {
+		p.parts = append(p.parts, patternPart{Literal: expression[partStart:], Count: 1})
+	}
+
+	// Range expressions are parsed after the whole expression is checked
+	for _, i := range p.ranges {
+		if err := p.parseRange(&p.parts[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	// The value of the last part changes with every hostname
+	stride := 1
+	for i := len(p.parts) - 1; i >= 0 && p.count != tooMany; i-- {
+		p.parts[i].Stride = stride
+		stride *= p.parts[i].Count
+	}
+	return p, nil
+}
+
+// parseRange parses the range expression in the Literal of a part into its elements.
+func (p *Pattern) parseRange(part *patternPart) error {
+	expression := part.Literal
+	if expression == "" {
+		return ErrEmptyExpression
+	}
+
+	part.Literal = ""
+	part.Elements = []rangeElement{}
+	for _, expr := range strings.Split(expression, ",") {
+		element, err := parseRangeElement(expr)
+		if err != nil {
+			return err
+		}
+		element.Offset = part.Count
+		if part.Count == tooMany || element.Count == tooMany || part.Count > math.MaxInt-element.Count {
+			part.Count = tooMany
+		} else {
+			part.Count += element.Count
+		}
+		part.Elements = append(part.Elements, element)
+	}
+
+	if p.count == tooMany || part.Count == tooMany || p.count > math.MaxInt/part.Count {
+		p.count = tooMany
+	} else {
+		p.count *= part.Count
+	}
+	return nil
+}
+
+// parseRangeElement parses a single value or a numeric range, e.g., `a`, `1` or `001-003`.
+func parseRangeElement(expr string) (rangeElement, error) {
+	start, end, found := strings.Cut(expr, "-")
+	if !found || !isNumber(start) || !isNumber(end) {
+		return rangeElement{Value: expr, Count: 1}, nil
+	}
+
+	// Numbers are compared as strings to support numbers longer than 64 bits
+	if utils.CompareNumbers(end, start) < 0 {
+		return rangeElement{}, ErrInvalidRange
+	}
+
+	element := rangeElement{IsNum: true}
+
+	// Check if there is leading zeroes
+	if start[0] == '0' || end[0] == '0' {
+		element.Width = max(len(start), len(end))
+	}
+
+	start, end = utils.TrimZeros(start), utils.TrimZeros(end)
+	element.Low, element.High = start, end
+	if len(end) < maxUintDigits {
+		s, _ := strconv.ParseUint(start, 10, 64)
+		e, _ := strconv.ParseUint(end, 10, 64)
+		element.Small = s
+		element.Count = tooMany
+		if e-s < math.MaxInt {
+			element.Count = int(e-s) + 1
+		}
+		return element, nil
+	}
+
+	s, _ := new(big.Int).SetString(start, 10)
+	e, _ := new(big.Int).SetString(end, 10)
+	count := e.Sub(e, s)
+	element.Start = s
+	element.Count = tooMany
+	if count.IsInt64() && count.Int64() < math.MaxInt {
+		element.Count = int(count.Int64()) + 1
+	}
+	return element, nil
+}
+
+// isNumber returns true if s is a non-empty string of ASCII digits
+func isNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Len returns the number of hostnames of the pattern, or -1 if the number does not fit in int
+func (p *Pattern) Len() int {
+	return p.count
+}
+
+// AppendHost appends the i-th hostname of the pattern to dst and returns the extended buffer.
+// Hostnames are indexed in the order of ExpandSingleExpression. i must be in [0, Len()).
+// dst is returned unchanged if the pattern has too many hostnames.
+func (p *Pattern) AppendHost(dst []byte, i int) []byte {
+	if p.count == tooMany {
+		return dst
+	}
+	for k := range p.parts {
+		part := &p.parts[k]
+		dst = part.appendValue(dst, (i/part.Stride)%part.Count)
+	}
+	return dst
+}
+
+// NumRanges returns the number of range expressions of the pattern
+func (p *Pattern) NumRanges() int {
+	return len(p.ranges)
+}
+
+// RangeIndex returns the index of the value of the k-th range expression in the i-th hostname.
+// i must be in [0, Len()) and k must be in [0, NumRanges()).
+//
+// RangeIndex returns -1 if the pattern has too many hostnames.
+//
+// For example, the 3rd hostname of `r[1-2]-n[5,7]`, `r2-n5`, has index 1 in range 0 and index 0 in range 1
+func (p *Pattern) RangeIndex(i, k int) int {
+	if p.count == tooMany {
+		return -1
+	}
+	part := &p.parts[p.ranges[k]]
+	return (i / part.Stride) % part.Count
+}
+
+// AppendRangeValue appends the value of the k-th range expression in the i-th hostname to dst,
+// including its zero padding. i must be in [0, Len()) and k must be in [0, NumRanges()).
+// dst is returned unchanged if the pattern has too many hostnames.
+func (p *Pattern) AppendRangeValue(dst []byte, i, k int) []byte {
+	if p.count == tooMany {
+		return dst
+	}
+	return p.parts[p.ranges[k]].appendValue(dst, p.RangeIndex(i, k))
+}
+
+// ForEach calls fn for each hostname of the pattern in the order of ExpandSingleExpression.
+// The buffer passed to fn is reused for the next hostname, so it must not be retained.
+// If fn returns false, ForEach stops the iteration and returns false.
+//
+// ForEach does not allocate memory for hostnames whose numbers fit in uint64.
+func (p *Pattern) ForEach(fn func(host []byte) bool) bool {
+	return p.ForEachRange(0, p.count, fn)
+}
+
+// ForEachRange is like ForEach, but only calls fn for the hostnames with index in [start, end).
+// Disjoint ranges of a pattern can be iterated concurrently.
+// A pattern with too many hostnames cannot be iterated, so fn is never called.
+func (p *Pattern) ForEachRange(start, end int, fn func(host []byte) bool) bool {
+	if p.count == tooMany {
+		return true
+	}
+	start, end = max(start, 0), min(end, p.count)
+
+	// Index of the current value of each part, i.e., the digits of a mixed-radix number
+	var indexBuffer [16]int
+	indexes := indexBuffer[:0]
+	for k := range p.parts {
+		indexes = append(indexes, (start/p.parts[k].Stride)%p.parts[k].Count)
+	}
+	var hostBuffer [64]byte
+	host := hostBuffer[:0]
+
+	for n := start; n < end; n++ {
+		host = host[:0]
+		for k := range p.parts {
+			host = p.parts[k].appendValue(host, indexes[k])
+		}
+		if !fn(host) {
+			return false
+		}
+
+		// Increment the mixed-radix number
+		for k := len(indexes) - 1; k >= 0; k-- {
+			indexes[k]++
+			if indexes[k] < p.parts[k].Count {
+				break
+			}
+			indexes[k] = 0
+		}
+	}
+	return true
+}
+
+// appendValue appends the i-th value of the part to dst
+func (part *patternPart) appendValue(dst []byte, i int) []byte {
+	if part.Elements == nil {
+		return append(dst, part.Literal...)
+	}
+
+	// Find the element containing the i-th value
+	lo, hi := 0, len(part.Elements)-1
+	for lo < hi {
+		mid := (lo + hi + 1) / 2
+		if part.Elements[mid].Offset <= i {
+			lo = mid
+		} else {
+			hi = mid - 1
+		}
+	}
+	return part.Elements[lo].appendValue(dst, i-part.Elements[lo].Offset)
+}
+
+// appendValue appends the i-th value of the element to dst, padded with zeroes
+func (e *rangeElement) appendValue(dst []byte, i int) []byte {
+	if !e.IsNum {
+		return append(dst, e.Value...)
+	}
+
+	var digitBuffer [maxUintDigits + 1]byte
+	var digits []byte
+	if e.Start == nil {
+		digits = strconv.AppendUint(digitBuffer[:0], e.Small+uint64(i), 10)
+	} else {
+		v := new(big.Int).Add(e.Start, big.NewInt(int64(i)))
+		digits = v.Append(digitBuffer[:0], 10)
+	}
+
+	for pad := e.Width - len(digits); pad > 0; pad-- {
+		dst = append(dst, '0')
+	}
+	return append(dst, digits...)
+}
+
+// Match returns true if host is one of the hostnames of the pattern. The hostname is parsed
+// against the parts of the pattern, checking the bounds and the zero padding of the numbers,
+// so the pattern is not expanded.
+//
+// Positions of the hostname which cannot be matched by the remaining parts are remembered,
+// so each part is matched at most once at each position of the hostname, and the values of
+// a range expression are found through an index instead of checking each element.
+// Match is safe for concurrent use.
+func (p *Pattern) Match(host string) bool {
+	p.indexOnce.Do(p.buildIndex)
+
+	// failed is a bitset of the pairs of part k and position i in host which cannot be matched
+	var failedBuffer [4]uint64
+	var failed []uint64
+	if words := (len(p.parts)*(len(host)+1) + 63) / 64; words <= len(failedBuffer) {
+		failed = failedBuffer[:words]
+	} else {
+		failed = make([]uint64, words)
+	}
+	return p.matchParts(host, 0, 0, failed)
+}
+
+// matchParts returns true if host[i:] matches the parts of the pattern starting from part k
+func (p *Pattern) matchParts(host string, k, i int, failed []uint64) bool {
+	if k == len(p.parts) {
+		return i == len(host)
+	}
+	bit := k*(len(host)+1) + i
+	if failed[bit/64]&(1<<(bit%64)) != 0 {
+		return false
+	}
+
+	if p.matchPart(host, k, i, failed) {
+		return true
+	}
+	failed[bit/64] |= 1 << (bit % 64)
+	return false
+}
+
+// matchPart returns true if a value of part k matches host at position i, and the remaining
+// parts match the rest of host
+func (p *Pattern) matchPart(host string, k, i int, failed []uint64) bool {
+	part := &p.parts[k]
+	rest := host[i:]
+	if part.Elements == nil {
+		return strings.HasPrefix(rest, part.Literal) && p.matchParts(host, k+1, i+len(part.Literal), failed)
+	}
+
+	index := &p.index[k]
+	for _, n := range index.ValueLengths {
+		if n <= len(rest) && index.Values[rest[:n]] && p.matchParts(host, k+1, i+n, failed) {
+			return true
+		}
+	}
+
+	digits := 0
+	for digits < len(rest) && digits < index.MaxDigits && rest[digits] >= '0' && rest[digits] <= '9' {
+		digits++
+	}
+	for n := 1; n <= digits; n++ {
+		number := rest[:n]
+		// A zero padded number has exactly Width digits. Otherwise, the number has no leading zeroes.
+		matched := index.Padded[n].contains(utils.TrimZeros(number))
+		if !matched && number[0] != '0' {
+			matched = index.Unpadded.contains(number)
+		}
+		if matched && p.matchParts(host, k+1, i+n, failed) {
+			return true
+		}
+	}
+	return false
+}
+
+// rangeIndex is an index of the values of a range expression for Pattern.Match
+type rangeIndex struct {
+	Values       map[string]bool      // Single values
+	ValueLengths []int                // Distinct lengths of the single values in ascending order
+	Unpadded     numberRanges         // Numeric ranges without zero padding
+	Padded       map[int]numberRanges // Numeric ranges with zero padding by their width
+	MaxDigits    int                  // Maximum number of digits of a number in the ranges
+}
+
+// numberRanges contains numeric ranges sorted by their lower bounds. Bounds are numbers
+// without leading zeroes.
+type numberRanges struct {
+	Low     []string
+	MaxHigh []string // Maximum upper bound of the ranges up to each range
+}
+
+// buildIndex builds the index of each range expression of the pattern
+func (p *Pattern) buildIndex() {
+	p.index = make([]rangeIndex, len(p.parts))
+	for k := range p.parts {
+		part := &p.parts[k]
+		if part.Elements == nil {
+			continue
+		}
+
+		index := rangeIndex{Values: map[string]bool{}, Padded: map[int]numberRanges{}}
+		lengths := map[int]bool{}
+		unpadded := []rangeElement{}
+		padded := map[int][]rangeElement{}
+		for _, e := range part.Elements {
+			switch {
+			case !e.IsNum:
+				index.Values[e.Value] = true
+				lengths[len(e.Value)] = true
+			case e.Width > 0:
+				padded[e.Width] = append(padded[e.Width], e)
+				index.MaxDigits = max(index.MaxDigits, e.Width)
+			default:
+				unpadded = append(unpadded, e)
+				index.MaxDigits = max(index.MaxDigits, len(e.High))
+			}
+		}
+		for n := range lengths {
+			index.ValueLengths = append(index.ValueLengths, n)
+		}
+		slices.Sort(index.ValueLengths)
+		index.Unpadded = newNumberRanges(unpadded)
+		for width, elements := range padded {
+			index.Padded[width] = newNumberRanges(elements)
+		}
+		p.index[k] = index
+	}
+}
+
+// newNumberRanges returns the ranges of numeric elements sorted by their lower bounds
+func newNumberRanges(elements []rangeElement) numberRanges {
+	slices.SortFunc(elements, func(a, b rangeElement) int { return utils.CompareNumbers(a.Low, b.Low) })
+	r := numberRanges{Low: make([]string, len(elements)), MaxHigh: make([]string, len(elements))}
+	for i, e := range elements {
+		r.Low[i] = e.Low
+		r.MaxHigh[i] = e.High
+		if i > 0 && utils.CompareNumbers(r.MaxHigh[i-1], e.High) > 0 {
+			r.MaxHigh[i] = r.MaxHigh[i-1]
+		}
+	}
+	return r
+}
+
+// contains returns true if number v, without leading zeroes, is in one of the ranges
+func (r numberRanges) contains(v string) bool {
+	// Number of ranges whose lower bound is not greater than v
+	i, found := slices.BinarySearchFunc(r.Low, v, utils.CompareNumbers)
+	if found {
+		return true
+	}
+	return i > 0 && utils.CompareNumbers(r.MaxHigh[i-1], v) >= 0
+}
@@ -0,0 +1,201 @@
+package expand
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/puttsk/hostlist/utils"
+)
+
+// Regexp returns a regular expression, without anchors, matching exactly the hostnames of
+// the pattern. A numeric range is converted to alternations of digit ranges, e.g., `08-11` becomes
+// `(?:0[8-9]|1[0-1])`. Only ASCII digits are matched by the digit ranges.
+func (p *Pattern) Regexp() string {
+	builder := strings.Builder{}
+	for _, part := range p.parts {
+		if part.Elements == nil {
+			builder.WriteString(regexp.QuoteMeta(part.Literal))
+			continue
+		}
+
+		alternatives := []string{}
+		for _, e := range part.Elements {
+			if !e.IsNum {
+				alternatives = append(alternatives, regexp.QuoteMeta(e.Value))
+			} else {
+				alternatives = append(alternatives, e.regexps()...)
+			}
+		}
+		builder.WriteString(group(alternatives))
+	}
+	return builder.String()
+}
+
+// regexps returns the alternatives of a regular expression matching the numbers of the element
+func (e *rangeElement) regexps() []string {
+	// A zero padded number has exactly Width digits
+	if e.Width > 0 {
+		return digitRanges(utils.PadZeros(e.Low, e.Width), utils.PadZeros(e.High, e.Width))
+	}
+
+	// A number without padding has no leading zero. The range is split by the number of digits.
+	alternatives := []string{}
+	for n := len(e.Low); n <= len(e.High); n++ {
+		lo, hi := "1"+strings.Repeat("0", n-1), strings.Repeat("9", n)
+		if n == len(e.Low) {
+			lo = e.Low
+		}
+		if n == len(e.High) {
+			hi = e.High
+		}
+		alternatives = append(alternatives, digitRanges(lo, hi)...)
+	}
+	return alternatives
+}
+
+// digitRanges returns the alternatives of a regular expression matching the strings of digits
+// from lo to hi. lo and hi must have the same length and lo <= hi.
+//
+// For example:
+//
+//	`08` to `11` becomes `0[8-9]` and `1[0-1]`
+func digitRanges(lo, hi string) []string {
+	if lo == hi {
+		return []string{lo}
+	}
+
+	// The common prefix is matched literally
+	p := 0
+	for lo[p] == hi[p] {
+		p++
+	}
+	if p > 0 {
+		return []string{lo[:p] + group(digitRanges(lo[p:], hi[p:]))}
+	}
+
+	n := len(lo)
+	if n == 1 {
+		return []string{digitClass(lo[0], hi[0])}
+	}
+
+	alternatives := []string{}
+
+	// Numbers starting with the first digit of lo, unless all of them are in the range
+	midLo := lo[0]
+	if strings.Trim(lo[1:], "0") != "" {
+		alternatives = append(alternatives, lo[:1]+group(digitRanges(lo[1:], strings.Repeat("9", n-1))))
+		midLo++
+	}
+
+	// Numbers starting with the first digit of hi, unless all of them are in the range
+	midHi := hi[0]
+	last := []string{}
+	if strings.Trim(hi[1:], "9") != "" {
+		last = append(last, hi[:1]+group(digitRanges(strings.Repeat("0", n-1), hi[1:])))
+		midHi--
+	}
+
+	// Numbers starting with the digits between them can have any remaining digits
+	if midLo <= midHi {
+		alternatives = append(alternatives, digitClass(midLo, midHi)+anyDigits(n-1))
+	}
+	return append(alternatives, last...)
+}
+
+// digitClass returns a regular expression matching a digit from lo to hi
+func digitClass(lo, hi byte) string {
+	if lo == hi {
+		return string(lo)
+	}
+	return "[" + string(lo) + "-" + string(hi) + "]"
+}
+
+// anyDigits returns a regular expression matching n digits
+func anyDigits(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "[0-9]"
+	}
+	return "[0-9]{" + strconv.Itoa(n) + "}"
+}
+
+// group returns a regular expression matching any of the alternatives
+func group(alternatives []string) string {
+	if len(alternatives) == 1 {
+		return alternatives[0]
+	}
+	return "(?:" + strings.Join(alternatives, "|") + ")"
+}
+
+// Glob returns a shell glob pattern matching the hostnames of the pattern. Glob is best effort:
+// a range expression which cannot be represented by character classes is replaced with `*`,
+// so the glob can match more hostnames than the pattern.
+//
+// For example:
+//
+//	`node[001-100]` becomes `node[0-1][0-9][0-9]`
+//	`node[1-100]` becomes `node*`
+func (p *Pattern) Glob() string {
+	builder := strings.Builder{}
+	for _, part := range p.parts {
+		if part.Elements == nil {
+			builder.WriteString(part.Literal)
+			continue
+		}
+		builder.WriteString(part.glob())
+	}
+	return builder.String()
+}
+
+// glob returns a glob pattern matching the values of a range expression
+func (part *patternPart) glob() string {
+	// A range with single characters becomes a character class
+	if chars, ok := part.chars(); ok {
+		return "[" + chars + "]"
+	}
+
+	// A single range of numbers with the same number of digits becomes a character class per digit
+	if len(part.Elements) == 1 && part.Elements[0].IsNum {
+		e := part.Elements[0]
+		lo, hi := e.Low, e.High
+		if e.Width > 0 {
+			lo, hi = utils.PadZeros(lo, e.Width), utils.PadZeros(hi, e.Width)
+		}
+		if len(lo) == len(hi) {
+			builder := strings.Builder{}
+			for i := range lo {
+				switch {
+				case lo[:i] != hi[:i]:
+					builder.WriteString("[0-9]")
+				case lo[i] == hi[i]:
+					builder.WriteByte(lo[i])
+				default:
+					builder.WriteString(digitClass(lo[i], hi[i]))
+				}
+			}
+			return builder.String()
+		}
+	}
+	return "*"
+}
+
+// chars returns the content of a glob character class matching the values of a range expression.
+// ok is false if a value has more than one character, or if a value is `-`, which cannot be
+// placed in a character class without being read as a range of characters by path.Match.
+func (part *patternPart) chars() (string, bool) {
+	builder := strings.Builder{}
+	for _, e := range part.Elements {
+		switch {
+		case !e.IsNum && len(e.Value) == 1 && e.Value != "-":
+			builder.WriteString(e.Value)
+		case e.IsNum && max(e.Width, len(e.High)) == 1:
+			builder.WriteString(e.Low + "-" + e.High)
+		default:
+			return "", false
+		}
+	}
+	return builder.String(), true
+}
@@ -0,0 +1,34 @@
+package hostlist
+
+// Set expands a hostlist expression and adds the hostnames to the list. Hostnames already in
+// the list are not added again, so the list can be set by repeated flags, e.g.,
+// `-w node[1-4] -w node[3-8]`. Together with String and Type, Set implements flag.Value
+// and the Value interface of github.com/spf13/pflag.
+//
+// For example:
+//
+//	var nodes hostlist.Hostlist
+//	flag.Var(&nodes, "w", "List of nodes")
+func (h *Hostlist) Set(value string) error {
+	hosts, err := Expand(value)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(*h)+len(hosts))
+	for _, host := range *h {
+		seen[host] = true
+	}
+	for _, host := range hosts {
+		if !seen[host] {
+			seen[host] = true
+			*h = append(*h, host)
+		}
+	}
+	return nil
+}
+
+// Type returns the name of the value type, which is shown in the help message of pflag
+func (h *Hostlist) Type() string {
+	return "hostlist"
+}
@@ -0,0 +1,80 @@
+package hostlist_test
+
+import (
+	"bytes"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+)
+
+type HostlistFlagTestcase struct {
+	Args           []string
+	ExpectedResult hostlist.Hostlist
+	ExpectedError  bool
+}
+
+var HostlistFlagTestcases = []HostlistFlagTestcase{
+	{
+		Args:           []string{},
+		ExpectedResult: hostlist.Hostlist{"node3"},
+	},
+	{
+		Args:           []string{"-w", "node[1-2]"},
+		ExpectedResult: hostlist.Hostlist{"node3", "node1", "node2"},
+	},
+	{
+		Args:           []string{"-w", "node[1-2]", "-w", "node[2-4],login"},
+		ExpectedResult: hostlist.Hostlist{"node3", "node1", "node2", "node4", "login"},
+	},
+	{
+		Args:          []string{"-w", "node[1-"},
+		ExpectedError: true,
+	},
+}
+
+// TestHostlistFlag tests hostlist.Hostlist as flag.Value
+func TestHostlistFlag(t *testing.T) {
+	for _, c := range HostlistFlagTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Args, " "))
+		flags := flag.NewFlagSet("test", flag.ContinueOnError)
+		flags.SetOutput(&bytes.Buffer{})
+
+		// Hostnames from flags are added to the default value
+		nodes := hostlist.Hostlist{"node3"}
+		flags.Var(&nodes, "w", "List of nodes")
+		err := flags.Parse(c.Args)
+		if (err != nil) != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %v expected error: %v", err, c.ExpectedError)
+		}
+		if err != nil {
+			continue
+		}
+		if !reflect.DeepEqual(nodes, c.ExpectedResult) {
+			t.Fatalf("Invalid hostnames: actual: %v expect: %v", nodes, c.ExpectedResult)
+		}
+	}
+}
+
+// TestHostlistFlagDefaults checks that the default value is printed as a hostlist expression
+func TestHostlistFlagDefaults(t *testing.T) {
+	output := &bytes.Buffer{}
+	flags := flag.NewFlagSet("test", flag.ContinueOnError)
+	flags.SetOutput(output)
+
+	nodes := hostlist.Hostlist{"node1", "node2", "node3"}
+	var empty hostlist.Hostlist
+	flags.Var(&nodes, "w", "List of `nodes`")
+	flags.Var(&empty, "x", "List of excluded nodes")
+	flags.PrintDefaults()
+
+	expected := "  -w nodes\n    \tList of nodes (default node[1-3])\n  -x value\n    \tList of excluded nodes\n"
+	if output.String() != expected {
+		t.Fatalf("Invalid defaults: actual:\n%s\nexpect:\n%s", output.String(), expected)
+	}
+	if nodes.Type() != "hostlist" {
+		t.Fatalf("Invalid type: actual: %s expect: %s", nodes.Type(), "hostlist")
+	}
+}
@@ -0,0 +1,162 @@
+package hostlist
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// templateKind is the kind of a segment of a template
+type templateKind int
+
+const (
+	templateLiteral    templateKind = iota // Literal text
+	templateHost                           // {host}
+	templateIndex                          // {idx}
+	templateValue                          // {nK}
+	templateRangeIndex                     // {iK}
+)
+
+// templateSegment is a literal text or a variable of a template
+type templateSegment struct {
+	Kind    templateKind
+	Literal string // Text of a literal segment
+	Range   int    // K of {nK} and {iK}
+}
+
+// parseTemplate splits a template into literal texts and variables
+func parseTemplate(template string) ([]templateSegment, error) {
+	segments := []templateSegment{}
+	literal := strings.Builder{}
+	for i := 0; i < len(template); i++ {
+		switch {
+		case strings.HasPrefix(template[i:], "{{"), strings.HasPrefix(template[i:], "}}"):
+			literal.WriteByte(template[i])
+			i++
+			continue
+		case template[i] == '}':
+			return nil, fmt.Errorf("%w: unexpected '}' at position %d", ErrInvalidTemplate, i+1)
+		case template[i] != '{':
+			literal.WriteByte(template[i])
+			continue
+		}
+
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("%w: cannot find matching '}' at position %d", ErrInvalidTemplate, i+1)
+		}
+		name := template[i+1 : i+end]
+
+		segment := templateSegment{}
+		switch {
+		case name == "host":
+			segment.Kind = templateHost
+		case name == "idx":
+			segment.Kind = templateIndex
+		case len(name) > 1 && (name[0] == 'n' || name[0] == 'i'):
+			k, err := strconv.Atoi(name[1:])
+			if err != nil || !isDigits(name[1:]) {
+				return nil, fmt.Errorf("%w: unknown variable {%s}", ErrInvalidTemplate, name)
+			}
+			segment.Kind, segment.Range = templateValue, k
+			if name[0] == 'i' {
+				segment.Kind = templateRangeIndex
+			}
+		default:
+			return nil, fmt.Errorf("%w: unknown variable {%s}", ErrInvalidTemplate, name)
+		}
+
+		if literal.Len() > 0 {
+			segments = append(segments, templateSegment{Kind: templateLiteral, Literal: literal.String()})
+			literal.Reset()
+		}
+		segments = append(segments, segment)
+		i += end
+	}
+	if literal.Len() > 0 {
+		segments = append(segments, templateSegment{Kind: templateLiteral, Literal: literal.String()})
+	}
+	return segments, nil
+}
+
+// isDigits returns true if s consists of ASCII digits only
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if !isDigit(s[i]) {
+			return false
+		}
+	}
+	return len(s) > 0
+}
+
+// ExpandFormat expands hostnames from hostlist expression and formats each hostname with a template.
+// The template can contain the following variables:
+//
+//	{host}  The hostname
+//	{idx}   The position of the hostname in the expansion, starting from 0
+//	{nK}    The value of the K-th range expression in the hostname including its zero padding, e.g., {n0}
+//	{iK}    The position of the value in the K-th range expression, starting from 0
+//
+// Use `{{` and `}}` for literal braces. A range expression which does not exist in the single
+// expression of the hostname is replaced with an empty string. An error wrapping ErrInvalidTemplate
+// is returned if the template is malformed, or if K is past the last range expression of every
+// single expression, e.g., {n2} for `rack[1-2]-node[08-09]`.
+//
+// For example:
+//
+//	ExpandFormat("rack[1-2]-node[08-09]", "{host}.ib:{n1}") returns
+//	`["rack1-node08.ib:08", "rack1-node09.ib:09", "rack2-node08.ib:08", "rack2-node09.ib:09"]`
+func ExpandFormat(expression string, template string) ([]string, error) {
+	segments, err := parseTemplate(template)
+	if err != nil {
+		return nil, err
+	}
+
+	patterns, err := parsePatterns(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	maxRanges := 0
+	for _, p := range patterns {
+		maxRanges = max(maxRanges, p.NumRanges())
+	}
+	for _, s := range segments {
+		if (s.Kind == templateValue || s.Kind == templateRangeIndex) && s.Range >= maxRanges {
+			return nil, fmt.Errorf("%w: range expression %d does not exist in %s", ErrInvalidTemplate, s.Range, expression)
+		}
+	}
+
+	results := []string{}
+	buffer := []byte{}
+	idx := 0
+	for _, p := range patterns {
+		for i := 0; i < p.Len(); i++ {
+			buffer = buffer[:0]
+			for _, s := range segments {
+				switch s.Kind {
+				case templateLiteral:
+					buffer = append(buffer, s.Literal...)
+				case templateHost:
+					buffer = p.AppendHost(buffer, i)
+				case templateIndex:
+					buffer = strconv.AppendInt(buffer, int64(idx), 10)
+				case templateValue:
+					if s.Range < p.NumRanges() {
+						buffer = p.AppendRangeValue(buffer, i, s.Range)
+					}
+				case templateRangeIndex:
+					if s.Range < p.NumRanges() {
+						buffer = strconv.AppendInt(buffer, int64(p.RangeIndex(i, s.Range)), 10)
+					}
+				}
+			}
+			results = append(results, string(buffer))
+			idx++
+		}
+	}
+	return results, nil
+}
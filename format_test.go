@@ -0,0 +1,109 @@
+package hostlist_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+	"github.com/puttsk/hostlist/expand"
+)
+
+type ExpandFormatTestcase struct {
+	HostlistExpression string
+	Template           string
+	ExpectedResult     []string
+	ExpectedError      error
+}
+
+var ExpandFormatTestcases = []ExpandFormatTestcase{
+	{
+		HostlistExpression: "rack[1-2]-node[08-09]",
+		Template:           "{host}.ib:{n1}",
+		ExpectedResult:     []string{"rack1-node08.ib:08", "rack1-node09.ib:09", "rack2-node08.ib:08", "rack2-node09.ib:09"},
+	},
+	{
+		HostlistExpression: "node[3,5-6],login",
+		Template:           "{idx} {host}-ib0 {n0} {i0}",
+		ExpectedResult:     []string{"0 node3-ib0 3 0", "1 node5-ib0 5 1", "2 node6-ib0 6 2", "3 login-ib0  "},
+	},
+	{
+		HostlistExpression: "n[1-2],rack[1]-node[08-09]",
+		Template:           "{host}:{n1}",
+		ExpectedResult:     []string{"n1:", "n2:", "rack1-node08:08", "rack1-node09:09"},
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "{{{host}}}",
+		ExpectedResult:     []string{"{n1}", "{n2}"},
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "static",
+		ExpectedResult:     []string{"static", "static"},
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "{hostname}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "{n}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "{n+0}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "{i-0}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "rack[1-2]-node[08-09]",
+		Template:           "{host}:{n2}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "node[3,5-6],login",
+		Template:           "{i1}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "login",
+		Template:           "{n0}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "{host",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "n[1-2]",
+		Template:           "host}",
+		ExpectedError:      hostlist.ErrInvalidTemplate,
+	},
+	{
+		HostlistExpression: "n[1-",
+		Template:           "{host}",
+		ExpectedError:      expand.ErrExpectedCloseBracket,
+	},
+}
+
+// TestExpandFormat tests hostlist.ExpandFormat
+func TestExpandFormat(t *testing.T) {
+	for _, c := range ExpandFormatTestcases {
+		t.Logf("Testcase: %s %s\n", c.HostlistExpression, c.Template)
+		results, err := hostlist.ExpandFormat(c.HostlistExpression, c.Template)
+		if !errors.Is(err, c.ExpectedError) || (err == nil) != (c.ExpectedError == nil) {
+			t.Fatalf("Invalid error: actual: %v expected: %v", err, c.ExpectedError)
+		}
+		if !reflect.DeepEqual(results, c.ExpectedResult) {
+			t.Fatalf("Invalid results: actual: %q expect: %q", results, c.ExpectedResult)
+		}
+	}
+}
@@ -3,7 +3,11 @@
 package hostlist
 
 import (
+	"math"
+	"runtime"
 	"slices"
+	"strings"
+	"sync"
 
 	"github.com/puttsk/hostlist/compress"
 	"github.com/puttsk/hostlist/expand"
@@ -15,8 +19,150 @@ import (
 //
 //	`host-[001-003]` will be converted to `["host-001", "host-002", "host-003"]`
 func Expand(expression string) ([]string, error) {
-	hostlist := []string{}
+	if expression == "" {
+		return nil, expand.ErrEmptyExpression
+	}
+	hosts, err := AppendExpand([]string{}, expression)
+	if err != nil {
+		return nil, err
+	}
+	return hosts, nil
+}
+
+// AppendExpand expands hostnames from hostlist expression, appends them to dst and returns
+// the extended list. The hostnames of each expression share a single block of memory, so
+// expanding n hostnames takes a constant number of allocations instead of n.
+// If an error occurs, dst is returned unchanged.
+//
+// For example:
+//
+//	AppendExpand(hosts[:0], "host-[1-2]") reuses the list hosts and returns `["host-1", "host-2"]`
+func AppendExpand(dst []string, expression string) ([]string, error) {
+	patterns, err := parsePatterns(expression)
+	if err != nil {
+		return dst, err
+	}
+
+	for _, p := range patterns {
+		dst = appendHosts(dst, p, 0, p.Len())
+	}
+	return dst, nil
+}
+
+// appendHosts appends the hostnames of pattern p with index in [start, end) to dst.
+// The hostnames share a single block of memory.
+func appendHosts(dst []string, p *expand.Pattern, start, end int) []string {
+	// Compute the total length of the hostnames to allocate the memory only once
+	size := 0
+	p.ForEachRange(start, end, func(host []byte) bool {
+		size += len(host)
+		return true
+	})
+
+	// Hostnames are substrings of the builder. The builder never modifies bytes already written.
+	builder := strings.Builder{}
+	builder.Grow(size)
+	dst = slices.Grow(dst, end-start)
+	p.ForEachRange(start, end, func(host []byte) bool {
+		offset := builder.Len()
+		builder.Write(host)
+		dst = append(dst, builder.String()[offset:])
+		return true
+	})
+	return dst
+}
+
+// ExpandParallel expands hostnames from hostlist expression using multiple goroutines and returns
+// the same list of hostnames as Expand. If workers is less than 1, runtime.GOMAXPROCS(0) goroutines are used.
+//
+// The hostnames are indexed as mixed-radix numbers, whose digits are the indexes of the values of
+// each range expression. Each goroutine generates a contiguous part of the list directly into
+// a list allocated once for all hostnames.
+func ExpandParallel(expression string, workers int) ([]string, error) {
+	patterns, err := parsePatterns(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	// Index of the first hostname of each pattern
+	offsets := make([]int, len(patterns)+1)
+	for i, p := range patterns {
+		if offsets[i] > math.MaxInt-p.Len() {
+			return nil, expand.ErrTooManyHosts
+		}
+		offsets[i+1] = offsets[i] + p.Len()
+	}
+	total := offsets[len(patterns)]
+
+	if workers < 1 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	// Small lists are not worth the cost of starting goroutines
+	workers = min(workers, (total+minParallelHosts-1)/minParallelHosts)
+	shard := (total + workers - 1) / workers
+
+	hosts := make([]string, total)
+	wg := sync.WaitGroup{}
+	for start := 0; start < total; start += shard {
+		wg.Add(1)
+		go func(start, end int) {
+			defer wg.Done()
+			// Hostnames are appended in place of the shard
+			dst := hosts[start:start:end]
+			for i, p := range patterns {
+				lo, hi := max(start, offsets[i]), min(end, offsets[i+1])
+				if lo < hi {
+					dst = appendHosts(dst, p, lo-offsets[i], hi-offsets[i])
+				}
+			}
+		}(start, min(start+shard, total))
+	}
+	wg.Wait()
+
+	return hosts, nil
+}
+
+// minParallelHosts is the minimum number of hostnames generated by a goroutine of ExpandParallel
+const minParallelHosts = 4096
+
+// ExpandFunc calls fn for each hostname of hostlist expression in the order of Expand.
+// The buffer passed to fn is reused for the next hostname, so it must be copied to be retained.
+// If fn returns false, ExpandFunc stops the expansion. The expression is checked before
+// fn is called for the first hostname.
+//
+// ExpandFunc does not allocate memory for each hostname.
+func ExpandFunc(expression string, fn func(host []byte) bool) error {
+	patterns, err := parsePatterns(expression)
+	if err != nil {
+		return err
+	}
 
+	for _, p := range patterns {
+		if !p.ForEach(fn) {
+			break
+		}
+	}
+	return nil
+}
+
+// parsePatterns splits hostlist expression and parses each single expression for expanding.
+// parsePatterns returns expand.ErrTooManyHosts if the number of hostnames of a single expression
+// does not fit in int.
+func parsePatterns(expression string) ([]*expand.Pattern, error) {
+	patterns, err := splitPatterns(expression)
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range patterns {
+		if p.Len() < 0 {
+			return nil, expand.ErrTooManyHosts
+		}
+	}
+	return patterns, nil
+}
+
+// splitPatterns splits hostlist expression and parses each single expression
+func splitPatterns(expression string) ([]*expand.Pattern, error) {
 	if expression == "" {
 		return nil, expand.ErrEmptyExpression
 	}
@@ -26,25 +172,100 @@ func Expand(expression string) ([]string, error) {
 		return nil, err
 	}
 
-	for _, expr := range expressions {
-		hosts, err := expand.ExpandSingleExpression(expr)
+	patterns := make([]*expand.Pattern, len(expressions))
+	for i, expr := range expressions {
+		patterns[i], err = expand.ParsePattern(expr)
 		if err != nil {
 			return nil, err
 		}
-		hostlist = append(hostlist, hosts...)
 	}
-
-	return hostlist, nil
+	return patterns, nil
 }
 
-// Compress return hostlist expression from a list of host
+// Compress return hostlist expression from a list of host.
+// The hosts can be in any order and the list is not modified.
 func Compress(hosts []string) (string, error) {
 	tree := compress.NewHostlistExpressionTree()
-	slices.Sort(hosts)
+	tree.AddHosts(hosts)
+
+	return tree.GetExpression(), nil
+}
+
+// CompressWithOptions return hostlist expression from a list of host using options opts,
+// and a list of numbers which could not be joined into range expressions because of their
+// zero padding.
+//
+// For example, with compress.PaddingNormalize:
+//
+//	`["host1", "host002", "host3"]` will be converted to `host[001-003]`
+func CompressWithOptions(hosts []string, opts compress.Options) (string, []compress.PaddingWarning, error) {
+	tree := compress.NewHostlistExpressionTree()
+	tree.Options = opts
+	tree.AddHosts(hosts)
 
+	expr, warnings := tree.GetExpressionWithWarnings()
+	return expr, warnings, nil
+}
+
+// CompressWithReport return hostlist expression from a list of host using options opts, and a report
+// explaining the result: ranges split because of different zero padding, number groups not merged
+// because of different suffixes, duplicated hostnames removed, and the compression ratio.
+func CompressWithReport(hosts []string, opts compress.Options) (string, compress.Report, error) {
+	tree := compress.NewHostlistExpressionTree()
+	tree.Options = opts
+
+	seen := make(map[string]bool, len(hosts))
+	unique := make([]string, 0, len(hosts))
+	duplicates := []string{}
+	inputLength := 0
 	for _, h := range hosts {
-		tree.AddHost(h)
+		// Empty hostnames are ignored by AddHost
+		if h == "" {
+			continue
+		}
+		if seen[h] {
+			duplicates = append(duplicates, h)
+			continue
+		}
+		seen[h] = true
+		inputLength += len(h)
+		unique = append(unique, h)
+	}
+	tree.AddHosts(unique)
+	if len(seen) > 1 {
+		inputLength += len(seen) - 1 // ',' separators
 	}
 
-	return tree.GetExpression(), nil
+	expr, report := tree.GetExpressionWithReport()
+	report.Duplicates = duplicates
+	report.Hosts = len(seen)
+	report.InputLength = inputLength
+	report.OutputLength = len(expr)
+	if len(expr) > 0 {
+		report.CompressionRatio = float64(inputLength) / float64(len(expr))
+	}
+
+	return expr, report, nil
+}
+
+// Fold returns hostlist expression from a list of host using multi-dimensional folding.
+//
+// Fold finds rectangular Cartesian products across all numeric fields of hostnames sharing
+// the same literal template. Unlike Compress, the result never contains a nested range
+// expression and can always be expanded by Expand.
+//
+// For example:
+//
+//	`["rack1-node01", "rack1-node02", "rack2-node01", "rack2-node02"]` will be converted to `rack[1-2]-node[01-02]`
+func Fold(hosts []string) (string, error) {
+	return compress.FoldHosts(hosts), nil
+}
+
+// FoldMinimal returns the hostlist expression with the lowest cost from a list of host.
+// If cost is nil, compress.LengthCost is used and FoldMinimal returns the shortest expression found.
+// Use compress.GroupCost to minimize the number of top-level expressions instead.
+//
+// Like Fold, the result never contains a nested range expression.
+func FoldMinimal(hosts []string, cost compress.CostFunc) (string, error) {
+	return compress.FoldHostsMinimal(hosts, cost), nil
 }
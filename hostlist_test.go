@@ -1,11 +1,15 @@
 package hostlist_test
 
 import (
+	"fmt"
+	"math/rand"
 	"reflect"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/puttsk/hostlist"
+	"github.com/puttsk/hostlist/compress"
 	"github.com/puttsk/hostlist/expand"
 )
 
@@ -180,12 +184,12 @@ var CompressHostlistTestcases = []CompressHostlistTestcase{
 	},
 	{
 		Hostlist:       []string{"abcd", "abef", "abeg", "xyz", "x1z", "x2z"},
-		ExpectedResult: "ab[cd,e[f,g]],x[yz,[1-2]z]",
+		ExpectedResult: "ab[cd,e[f,g]],x[[1-2]z,yz]",
 		ExpectedError:  nil,
 	},
 	{
 		Hostlist:       []string{"host-01", "a", "b", "host-03", "host-02", "10-host-120", "11-host-120", "zz-01-a", "yz-01-b", "yz-02-v", "yz-02x"},
-		ExpectedResult: "a,b,host-[01-03],yz-[01-b,02[-v,x]],zz-01-a,[10-11]-host-120",
+		ExpectedResult: "[10-11]-host-120,a,b,host-[01-03],yz-[01-b,02[-v,x]],zz-01-a",
 		ExpectedError:  nil,
 	},
 }
@@ -221,6 +225,11 @@ var ExpandCompressHostlistTestcases = []ExpandCompressTestcase{
 		ExpectedExpandError:   nil,
 		ExpectedCompressError: nil,
 	},
+	{
+		HostlistExpression:    "node[000123456789012345678998-000123456789012345679001]",
+		ExpectedExpandError:   nil,
+		ExpectedCompressError: nil,
+	},
 	// {
 	// 	HostlistExpression:    "host-[a,001-004],other2-[08-11]",
 	// 	ExpectedExpandError:   nil,
@@ -243,12 +252,84 @@ func TestExpandHostlist(t *testing.T) {
 	}
 }
 
+// TestAppendExpand calls hostlist.AppendExpand with hostlist expression, checking that
+// the hostnames are appended to the existing list.
+func TestAppendExpand(t *testing.T) {
+	for _, c := range ExpandHostlistTestcases {
+		t.Logf("Testcase: %s\n", c.HostlistExpression)
+		dst := []string{"existing"}
+		hostnames, err := hostlist.AppendExpand(dst, c.HostlistExpression)
+		if err != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %s expected: %s", err, c.ExpectedError)
+		}
+		expected := append([]string{"existing"}, c.ExpectedResult...)
+		if !reflect.DeepEqual(hostnames, expected) {
+			t.Fatalf("Invalid hostnames: actual: %+v expect: %+v", hostnames, expected)
+		}
+	}
+}
+
+// TestExpandFunc calls hostlist.ExpandFunc with hostlist expression, checking that
+// the hostnames are the same as hostlist.Expand and the expansion can be stopped.
+func TestExpandFunc(t *testing.T) {
+	for _, c := range ExpandHostlistTestcases {
+		t.Logf("Testcase: %s\n", c.HostlistExpression)
+		var hostnames []string
+		err := hostlist.ExpandFunc(c.HostlistExpression, func(host []byte) bool {
+			hostnames = append(hostnames, string(host))
+			return true
+		})
+		if err != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %s expected: %s", err, c.ExpectedError)
+		}
+		if !reflect.DeepEqual(hostnames, c.ExpectedResult) {
+			t.Fatalf("Invalid hostnames: actual: %+v expect: %+v", hostnames, c.ExpectedResult)
+		}
+	}
+
+	count := 0
+	hostlist.ExpandFunc("a[1-10],b[1-10]", func(host []byte) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatalf("Invalid number of hostnames: actual: %d expect: %d", count, 3)
+	}
+}
+
+// TestExpandParallel calls hostlist.ExpandParallel with hostlist expression, checking that
+// the hostnames are the same as hostlist.Expand for any number of goroutines.
+func TestExpandParallel(t *testing.T) {
+	testcases := slices.Clone(ExpandHostlistTestcases)
+	for _, expr := range []string{"r[1-30]-n[0001-1000],x[1-5000],y,z[1-9999]", "a[1-3][0-9][0-9][0-9][0-9]b"} {
+		hosts, _ := hostlist.Expand(expr)
+		testcases = append(testcases, ExpandHostlistTestcase{HostlistExpression: expr, ExpectedResult: hosts})
+	}
+
+	for _, c := range testcases {
+		t.Logf("Testcase: %s\n", c.HostlistExpression)
+		for _, workers := range []int{0, 1, 3, 8} {
+			hostnames, err := hostlist.ExpandParallel(c.HostlistExpression, workers)
+			if err != c.ExpectedError {
+				t.Fatalf("Invalid error: actual: %s expected: %s", err, c.ExpectedError)
+			}
+			if !reflect.DeepEqual(hostnames, c.ExpectedResult) {
+				t.Fatalf("Invalid hostnames with %d workers: actual: %+v expect: %+v", workers, hostnames, c.ExpectedResult)
+			}
+		}
+	}
+}
+
 // TestCompressHostlist tests TokenNode.CompressHostlist
 func TestCompressHostlist(t *testing.T) {
 	for _, c := range CompressHostlistTestcases {
 		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+		input := slices.Clone(c.Hostlist)
 		expression, err := hostlist.Compress(c.Hostlist)
 
+		if !slices.Equal(input, c.Hostlist) {
+			t.Fatalf("Input modified: actual: %+v expect: %+v", c.Hostlist, input)
+		}
 		if err != c.ExpectedError {
 			t.Fatalf("Invalid error: actual: %s expected: %s", err, c.ExpectedError)
 		}
@@ -275,3 +356,295 @@ func TestExpandCompressHostlist(t *testing.T) {
 		}
 	}
 }
+
+var ExpandFoldHostlistTestcases = []string{
+	"a",
+	"host1",
+	"host-[1-100]",
+	"p[1-2]_[3-4]s",
+	"prefix-[005-010]-suffix",
+	"rack[1-4]-node[01-16]",
+	"host-[001-004],other2-[08-11]",
+	"p[1-2]s[1-2]c[1-2]",
+}
+
+// TestExpandFoldHostlist expands hostlist expression, then folds the hostnames again,
+// checking that the result is the same expression.
+func TestExpandFoldHostlist(t *testing.T) {
+	for _, c := range ExpandFoldHostlistTestcases {
+		t.Logf("Testcase: %s\n", c)
+		hosts, err := hostlist.Expand(c)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+
+		// Fold must not depend on the order of hosts
+		slices.Reverse(hosts)
+		expression, err := hostlist.Fold(hosts)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if expression != c {
+			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", expression, c)
+		}
+	}
+}
+
+var FoldMinimalHostlistTestcases = []CompressHostlistTestcase{
+	{
+		Hostlist:       []string{},
+		ExpectedResult: "",
+		ExpectedError:  nil,
+	},
+	{
+		Hostlist:       []string{"rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2", "rack3-node1", "rack3-node2", "rack3-node3"},
+		ExpectedResult: "rack[1-3]-node[1-2],rack3-node3",
+		ExpectedError:  nil,
+	},
+}
+
+// TestFoldMinimalHostlist tests hostlist.FoldMinimal with the default cost function
+func TestFoldMinimalHostlist(t *testing.T) {
+	for _, c := range FoldMinimalHostlistTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+		expression, err := hostlist.FoldMinimal(c.Hostlist, nil)
+
+		if err != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %s expected: %s", err, c.ExpectedError)
+		}
+		if expression != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", expression, c.ExpectedResult)
+		}
+	}
+}
+
+type CompressWithOptionsTestcase struct {
+	Hostlist         []string
+	Options          compress.Options
+	ExpectedResult   string
+	ExpectedWarnings []compress.PaddingWarning
+}
+
+var CompressWithOptionsTestcases = []CompressWithOptionsTestcase{
+	{
+		Hostlist:         []string{},
+		Options:          compress.Options{},
+		ExpectedResult:   "",
+		ExpectedWarnings: nil,
+	},
+	{
+		Hostlist:       []string{"host9", "host10", "host011"},
+		Options:        compress.Options{Padding: compress.PaddingStrict},
+		ExpectedResult: "host[9-10,011]",
+		ExpectedWarnings: []compress.PaddingWarning{
+			{Prefix: "host", Suffix: "", Lower: "10", Upper: "011"},
+		},
+	},
+	{
+		Hostlist:         []string{"host9", "host10", "host011"},
+		Options:          compress.Options{Padding: compress.PaddingNormalize},
+		ExpectedResult:   "host[009-011]",
+		ExpectedWarnings: nil,
+	},
+}
+
+// TestCompressWithOptions tests hostlist.CompressWithOptions
+func TestCompressWithOptions(t *testing.T) {
+	for _, c := range CompressWithOptionsTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+		expression, warnings, err := hostlist.CompressWithOptions(c.Hostlist, c.Options)
+
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if expression != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", expression, c.ExpectedResult)
+		}
+		if !reflect.DeepEqual(warnings, c.ExpectedWarnings) {
+			t.Fatalf("Invalid warnings: actual: %+v expect: %+v", warnings, c.ExpectedWarnings)
+		}
+	}
+}
+
+type CompressWithReportTestcase struct {
+	Hostlist       []string
+	ExpectedResult string
+	ExpectedReport compress.Report
+}
+
+var CompressWithReportTestcases = []CompressWithReportTestcase{
+	{
+		Hostlist:       []string{},
+		ExpectedResult: "",
+		ExpectedReport: compress.Report{Duplicates: []string{}},
+	},
+	{
+		Hostlist:       []string{"host1", "host2", "host3", "host4", "host2"},
+		ExpectedResult: "host[1-4]",
+		ExpectedReport: compress.Report{
+			Duplicates:       []string{"host2"},
+			Hosts:            4,
+			InputLength:      23,
+			OutputLength:     9,
+			CompressionRatio: 23.0 / 9.0,
+		},
+	},
+	{
+		Hostlist:       []string{"", "a", ""},
+		ExpectedResult: "a",
+		ExpectedReport: compress.Report{
+			Duplicates:       []string{},
+			Hosts:            1,
+			InputLength:      1,
+			OutputLength:     1,
+			CompressionRatio: 1,
+		},
+	},
+	{
+		Hostlist:       []string{"99b", "98b", "100b", "0101b"},
+		ExpectedResult: "[98-100,0101]b",
+		ExpectedReport: compress.Report{
+			PaddingWarnings: []compress.PaddingWarning{
+				{Prefix: "", Suffix: "b", Lower: "100", Upper: "0101"},
+			},
+			Duplicates:       []string{},
+			Hosts:            4,
+			InputLength:      18,
+			OutputLength:     14,
+			CompressionRatio: 18.0 / 14.0,
+		},
+	},
+	{
+		Hostlist:       []string{"n1a", "n2a", "n3b", "n4b"},
+		ExpectedResult: "n[[1-2]a,[3-4]b]",
+		ExpectedReport: compress.Report{
+			SuffixConflicts: []compress.SuffixConflict{
+				{Prefix: "n", Expressions: []string{"[1-2]a", "[3-4]b"}},
+			},
+			Duplicates:       []string{},
+			Hosts:            4,
+			InputLength:      15,
+			OutputLength:     16,
+			CompressionRatio: 15.0 / 16.0,
+		},
+	},
+}
+
+// TestCompressWithReport tests hostlist.CompressWithReport
+func TestCompressWithReport(t *testing.T) {
+	for _, c := range CompressWithReportTestcases {
+		t.Logf("Testcase: %s\n", strings.Join(c.Hostlist, ","))
+		expression, report, err := hostlist.CompressWithReport(c.Hostlist, compress.Options{})
+
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if expression != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual:\n%s\nexpect:\n%s\n", expression, c.ExpectedResult)
+		}
+		if !reflect.DeepEqual(report, c.ExpectedReport) {
+			t.Fatalf("Invalid report: actual: %+v expect: %+v", report, c.ExpectedReport)
+		}
+	}
+}
+
+// CompressBenchmarks contains the number of hosts for benchmarking hostlist.Compress
+var CompressBenchmarks = []int{10000, 100000, 1000000}
+
+// benchmarkHosts returns n hostnames in random order, with 64 nodes per rack and
+// 2 racks out of every 10 missing a node
+func benchmarkHosts(n int) []string {
+	hosts := make([]string, 0, n)
+	for i := 0; len(hosts) < n; i++ {
+		rack, node := i/64, i%64
+		if rack%5 == 0 && node == 7 {
+			continue
+		}
+		hosts = append(hosts, fmt.Sprintf("rack%d-node%02d", rack, node))
+	}
+	rand.New(rand.NewSource(1)).Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+	return hosts
+}
+
+func BenchmarkCompress(t *testing.B) {
+	for _, n := range CompressBenchmarks {
+		hosts := benchmarkHosts(n)
+		t.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hostlist.Compress(hosts)
+			}
+		})
+	}
+}
+
+// BenchmarkAddHost adds hosts one at a time in random order, as a long-running process
+// updating a tree does, with all numbers on the same level of the tree
+func BenchmarkAddHost(t *testing.B) {
+	for _, n := range CompressBenchmarks {
+		hosts := make([]string, n)
+		for i := range hosts {
+			hosts[i] = fmt.Sprintf("n%d", i)
+		}
+		rand.New(rand.NewSource(1)).Shuffle(len(hosts), func(i, j int) { hosts[i], hosts[j] = hosts[j], hosts[i] })
+
+		t.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				tree := compress.NewHostlistExpressionTree()
+				for _, h := range hosts {
+					tree.AddHost(h)
+				}
+				tree.GetExpression()
+			}
+		})
+	}
+}
+
+func BenchmarkFold(t *testing.B) {
+	for _, n := range CompressBenchmarks {
+		hosts := benchmarkHosts(n)
+		t.Run(fmt.Sprint(n), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hostlist.Fold(hosts)
+			}
+		})
+	}
+}
+
+// ExpandBenchmarks contains hostlist expressions for benchmarking hostlist.Expand
+var ExpandBenchmarks = []string{
+	"rack[1-100]-node[01-100]",
+	"rack[1-1000]-node[01-1000]",
+}
+
+func BenchmarkExpand(t *testing.B) {
+	for _, expr := range ExpandBenchmarks {
+		t.Run("Expand/"+expr, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hostlist.Expand(expr)
+			}
+		})
+		t.Run("AppendExpand/"+expr, func(b *testing.B) {
+			b.ReportAllocs()
+			hosts := []string{}
+			for i := 0; i < b.N; i++ {
+				hosts, _ = hostlist.AppendExpand(hosts[:0], expr)
+			}
+		})
+		t.Run("ExpandParallel/"+expr, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hostlist.ExpandParallel(expr, 0)
+			}
+		})
+		t.Run("ExpandFunc/"+expr, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				hostlist.ExpandFunc(expr, func(host []byte) bool { return true })
+			}
+		})
+	}
+}
@@ -0,0 +1,495 @@
+package hostlist
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/puttsk/hostlist/bitmap"
+	"github.com/puttsk/hostlist/utils"
+)
+
+// HostSet represents a set of hostnames. Hostnames are grouped by their pattern, i.e., the
+// hostname without its last number and the zero padding width of that number, and the numbers
+// of each pattern are stored in a compressed bitmap. A set of hundreds of thousands of
+// hostnames takes a few bytes per hostname, and sets can be combined without expanding them.
+//
+// For example, `node001`, `node002` and `node010` are stored as the pattern `node###` with
+// the bitmap `1,2,10`. Hostnames without a number, or with a number which does not fit in
+// uint64, are stored as strings.
+//
+// The zero value is an empty set.
+type HostSet struct {
+	patterns map[hostPattern]*bitmap.Bitmap
+	names    map[string]bool // Hostnames not represented by a pattern
+}
+
+// hostPattern represents hostnames which differ only by their last number
+type hostPattern struct {
+	Prefix string // Characters before the last number
+	Suffix string // Characters after the last number
+	Width  int    // Zero padding width of the number. 0 if the number is not zero padded
+}
+
+// NewHostSet returns a HostSet containing hosts
+func NewHostSet(hosts ...string) *HostSet {
+	s := &HostSet{}
+	for _, h := range hosts {
+		s.Add(h)
+	}
+	return s
+}
+
+// ParseHostSet returns a HostSet containing the hostnames of a hostlist expression.
+// The hostnames are added without creating the list of hostnames.
+func ParseHostSet(expression string) (*HostSet, error) {
+	s := &HostSet{}
+	err := ExpandFunc(expression, func(host []byte) bool {
+		s.addHost(host)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// splitHost returns the pattern and the last number of a hostname.
+// ok is false if the hostname cannot be represented by a pattern.
+func splitHost(host []byte) (p hostPattern, value uint64, ok bool) {
+	end := len(host)
+	for end > 0 && !isDigit(host[end-1]) {
+		end--
+	}
+	start := end
+	for start > 0 && isDigit(host[start-1]) {
+		start--
+	}
+	if start == end {
+		return hostPattern{}, 0, false
+	}
+
+	value, err := strconv.ParseUint(string(host[start:end]), 10, 64)
+	if err != nil {
+		return hostPattern{}, 0, false
+	}
+
+	p = hostPattern{Prefix: string(host[:start]), Suffix: string(host[end:])}
+	if host[start] == '0' && end-start > 1 {
+		p.Width = end - start
+	}
+	return p, value, true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// Add adds a hostname to the set. An empty hostname is ignored.
+func (s *HostSet) Add(host string) {
+	if host == "" {
+		return
+	}
+	s.addHost([]byte(host))
+}
+
+func (s *HostSet) addHost(host []byte) {
+	p, value, ok := splitHost(host)
+	if !ok {
+		if s.names == nil {
+			s.names = map[string]bool{}
+		}
+		s.names[string(host)] = true
+		return
+	}
+
+	if s.patterns == nil {
+		s.patterns = map[hostPattern]*bitmap.Bitmap{}
+	}
+	b, ok := s.patterns[p]
+	if !ok {
+		b = bitmap.New()
+		s.patterns[p] = b
+	}
+	b.Add(value)
+}
+
+// Remove removes a hostname from the set
+func (s *HostSet) Remove(host string) {
+	p, value, ok := splitHost([]byte(host))
+	if !ok {
+		delete(s.names, host)
+		return
+	}
+	if b, ok := s.patterns[p]; ok {
+		b.Remove(value)
+		if b.Len() == 0 {
+			delete(s.patterns, p)
+		}
+	}
+}
+
+// Contains returns true if the hostname is in the set
+func (s *HostSet) Contains(host string) bool {
+	p, value, ok := splitHost([]byte(host))
+	if !ok {
+		return s.names[host]
+	}
+	b, ok := s.patterns[p]
+	return ok && b.Contains(value)
+}
+
+// Len returns the number of hostnames in the set
+func (s *HostSet) Len() int {
+	n := len(s.names)
+	for _, b := range s.patterns {
+		n += b.Len()
+	}
+	return n
+}
+
+// Union returns a new set containing the hostnames in either s or a
+func (s *HostSet) Union(a *HostSet) *HostSet {
+	result := &HostSet{patterns: map[hostPattern]*bitmap.Bitmap{}, names: map[string]bool{}}
+	for _, set := range []*HostSet{s, a} {
+		for p, b := range set.patterns {
+			if r, ok := result.patterns[p]; ok {
+				result.patterns[p] = r.Union(b)
+			} else {
+				result.patterns[p] = b.Clone()
+			}
+		}
+		for name := range set.names {
+			result.names[name] = true
+		}
+	}
+	return result
+}
+
+// Intersect returns a new set containing the hostnames in both s and a
+func (s *HostSet) Intersect(a *HostSet) *HostSet {
+	result := &HostSet{patterns: map[hostPattern]*bitmap.Bitmap{}, names: map[string]bool{}}
+	for p, b := range s.patterns {
+		if other, ok := a.patterns[p]; ok {
+			if r := b.Intersect(other); r.Len() > 0 {
+				result.patterns[p] = r
+			}
+		}
+	}
+	for name := range s.names {
+		if a.names[name] {
+			result.names[name] = true
+		}
+	}
+	return result
+}
+
+// Difference returns a new set containing the hostnames in s but not in a
+func (s *HostSet) Difference(a *HostSet) *HostSet {
+	result := &HostSet{patterns: map[hostPattern]*bitmap.Bitmap{}, names: map[string]bool{}}
+	for p, b := range s.patterns {
+		r := b.Clone()
+		if other, ok := a.patterns[p]; ok {
+			r = b.Difference(other)
+		}
+		if r.Len() > 0 {
+			result.patterns[p] = r
+		}
+	}
+	for name := range s.names {
+		if !a.names[name] {
+			result.names[name] = true
+		}
+	}
+	return result
+}
+
+// Hosts returns the hostnames in the set in natural order
+func (s *HostSet) Hosts() []string {
+	hosts := make([]string, 0, s.Len())
+	for name := range s.names {
+		hosts = append(hosts, name)
+	}
+	for p, b := range s.patterns {
+		b.ForEach(func(v uint64) bool {
+			hosts = append(hosts, p.host(v))
+			return true
+		})
+	}
+	slices.SortFunc(hosts, utils.NaturalCompare)
+	return hosts
+}
+
+// host returns the hostname of the pattern with number v
+func (p hostPattern) host(v uint64) string {
+	return p.Prefix + utils.PadZeros(strconv.FormatUint(v, 10), p.Width) + p.Suffix
+}
+
+// String returns a hostlist expression representing the set. The expression is rendered from
+// the bitmaps directly, and the range expressions are ordered by their first hostname in
+// natural order. Patterns with the same template, i.e., the same characters around their
+// numbers and the same zero padding of each number, are folded into Cartesian products,
+// e.g., `rack[1-2]-node[01-16]`.
+func (s *HostSet) String() string {
+	expressions := []rendered{}
+	for name := range s.names {
+		expressions = append(expressions, rendered{First: name, Expression: name})
+	}
+
+	patterns := []*foldedPattern{}
+	for p, b := range s.patterns {
+		values := b
+		unpadded := hostPattern{Prefix: p.Prefix, Suffix: p.Suffix}
+		if p.Width > 0 {
+			// Numbers without padding having the same width belong to the padded range, e.g., `[09-10]`
+			if u, ok := s.patterns[unpadded]; ok {
+				values = values.Union(filterBitmap(u, func(v uint64) bool { return numDigits(v) == p.Width }))
+			}
+		} else {
+			// Numbers rendered in a padded range are removed
+			widths := map[int]bool{}
+			for q := range s.patterns {
+				if q.Prefix == p.Prefix && q.Suffix == p.Suffix && q.Width > 0 {
+					widths[q.Width] = true
+				}
+			}
+			if len(widths) > 0 {
+				values = filterBitmap(values, func(v uint64) bool { return !widths[numDigits(v)] })
+				if values.Len() == 0 {
+					continue
+				}
+			}
+		}
+
+		patterns = append(patterns, newFoldedPattern(p, values))
+	}
+
+	// Numbers without padding having the same width as a padded number at the same position
+	// belong to the padded range, e.g., `rack1-node[01-16]` but not `rack1-node[01-09,10-16]`
+	paddedWidths := map[string]map[int]bool{}
+	for _, f := range patterns {
+		for i, width := range f.Widths {
+			if width > 0 {
+				key := f.literalsKey(i)
+				if paddedWidths[key] == nil {
+					paddedWidths[key] = map[int]bool{}
+				}
+				paddedWidths[key][width] = true
+			}
+		}
+	}
+	for _, f := range patterns {
+		for i := range f.Widths[:len(f.Widths)-1] {
+			if v := firstValue(f.Dims[i]); f.Widths[i] == 0 && paddedWidths[f.literalsKey(i)][numDigits(v)] {
+				f.Widths[i] = numDigits(v)
+			}
+		}
+	}
+
+	// Patterns are folded in natural order, so the expression does not depend on the map order
+	slices.SortFunc(patterns, func(a, b *foldedPattern) int {
+		return utils.NaturalCompare(a.render().First, b.render().First)
+	})
+	groups := map[string][]*foldedPattern{}
+	keys := []string{}
+	for _, f := range patterns {
+		key := f.key()
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], f)
+	}
+
+	for _, key := range keys {
+		for _, f := range foldPatterns(groups[key]) {
+			expressions = append(expressions, f.render())
+		}
+	}
+
+	slices.SortFunc(expressions, func(a, b rendered) int {
+		return utils.NaturalCompare(a.First, b.First)
+	})
+	result := make([]string, len(expressions))
+	for i, e := range expressions {
+		result[i] = e.Expression
+	}
+	return strings.Join(result, ",")
+}
+
+// rendered represents a hostlist expression and its first hostname in natural order
+type rendered struct {
+	First      string
+	Expression string
+}
+
+// foldedPattern represents the hostnames of a Cartesian product of numbers. Literals[i] is the
+// text before the i-th number, and Literals[len(Dims)] is the text after the last number.
+type foldedPattern struct {
+	Literals []string
+	Widths   []int            // Zero padding width of each number. 0 if the number is not zero padded
+	Dims     []*bitmap.Bitmap // Values of each number
+}
+
+// newFoldedPattern returns a foldedPattern with the values of the last number of pattern p.
+// The numbers in the prefix of p, which fit in uint64, are single values of the other dimensions.
+func newFoldedPattern(p hostPattern, values *bitmap.Bitmap) *foldedPattern {
+	f := &foldedPattern{Literals: []string{""}}
+	prefix := p.Prefix
+	for i := 0; i < len(prefix); {
+		j := i
+		for j < len(prefix) && isDigit(prefix[j]) {
+			j++
+		}
+		v, err := strconv.ParseUint(prefix[i:j], 10, 64)
+		if j == i || err != nil {
+			// Characters and numbers which do not fit in uint64 are part of the literals
+			f.Literals[len(f.Literals)-1] += prefix[i : j+1]
+			i = j + 1
+			continue
+		}
+
+		width := 0
+		if prefix[i] == '0' && j-i > 1 {
+			width = j - i
+		}
+		f.Widths = append(f.Widths, width)
+		f.Dims = append(f.Dims, bitmap.New(v))
+		f.Literals = append(f.Literals, "")
+		i = j
+	}
+	f.Widths = append(f.Widths, p.Width)
+	f.Dims = append(f.Dims, values)
+	f.Literals = append(f.Literals, p.Suffix)
+	return f
+}
+
+// key returns a string identifying the template of the pattern. NUL cannot appear in hostnames.
+func (f *foldedPattern) key() string {
+	builder := strings.Builder{}
+	for i, literal := range f.Literals {
+		builder.WriteString(literal)
+		builder.WriteByte(0)
+		if i < len(f.Widths) {
+			builder.WriteString(strconv.Itoa(f.Widths[i]))
+			builder.WriteByte(0)
+		}
+	}
+	return builder.String()
+}
+
+// literalsKey returns a string identifying the literals of the pattern and the position i of a number
+func (f *foldedPattern) literalsKey(i int) string {
+	return strings.Join(f.Literals, "\x00") + "\x00" + strconv.Itoa(i)
+}
+
+// firstValue returns the smallest value of a non-empty bitmap
+func firstValue(b *bitmap.Bitmap) uint64 {
+	first := uint64(0)
+	b.ForEach(func(v uint64) bool {
+		first = v
+		return false
+	})
+	return first
+}
+
+// foldPatterns repeatedly merges patterns having identical values in every dimension except one,
+// until no more patterns can be merged. All patterns must have the same template.
+func foldPatterns(patterns []*foldedPattern) []*foldedPattern {
+	for {
+		count := len(patterns)
+		for d := len(patterns[0].Dims) - 1; d >= 0; d-- {
+			patterns = foldDimension(patterns, d)
+		}
+		if len(patterns) == count {
+			return patterns
+		}
+	}
+}
+
+// foldDimension merges patterns having identical values in every dimension except d
+func foldDimension(patterns []*foldedPattern, d int) []*foldedPattern {
+	result := []*foldedPattern{}
+	index := map[string]*foldedPattern{}
+	for _, f := range patterns {
+		keyBuilder := strings.Builder{}
+		for i, dim := range f.Dims {
+			if i != d {
+				keyBuilder.WriteString(renderRanges(dim, f.Widths[i]))
+			}
+			keyBuilder.WriteByte(0)
+		}
+		key := keyBuilder.String()
+
+		merged, ok := index[key]
+		if !ok {
+			merged = &foldedPattern{Literals: f.Literals, Widths: f.Widths, Dims: slices.Clone(f.Dims)}
+			index[key] = merged
+			result = append(result, merged)
+			continue
+		}
+		merged.Dims[d] = merged.Dims[d].Union(f.Dims[d])
+	}
+	return result
+}
+
+// render returns the hostlist expression of the pattern and its first hostname
+func (f *foldedPattern) render() rendered {
+	expression, first := strings.Builder{}, strings.Builder{}
+	for i, dim := range f.Dims {
+		expression.WriteString(f.Literals[i])
+		expression.WriteString(renderRanges(dim, f.Widths[i]))
+
+		// The first hostname consists of the smallest value of each dimension
+		first.WriteString(f.Literals[i])
+		first.WriteString(utils.PadZeros(strconv.FormatUint(firstValue(dim), 10), f.Widths[i]))
+	}
+	expression.WriteString(f.Literals[len(f.Dims)])
+	first.WriteString(f.Literals[len(f.Dims)])
+	return rendered{First: first.String(), Expression: expression.String()}
+}
+
+// renderRanges returns the values of a bitmap as a number, e.g., `5`, or as a range expression
+// in brackets, e.g., `[1-3,5]`, padded with zeroes to width.
+func renderRanges(values *bitmap.Bitmap, width int) string {
+	ranges := []string{}
+	values.Ranges(func(lo, hi uint64) bool {
+		// A range starting with zero is expanded with the width of its upper bound,
+		// so the range must stop before the width changes, e.g., `[0-9,10]` but not `[0-10]`.
+		if width == 0 && lo == 0 && hi >= 10 {
+			ranges = append(ranges, "0-9")
+			lo = 10
+		}
+		r := utils.PadZeros(strconv.FormatUint(lo, 10), width)
+		if hi > lo {
+			r += "-" + utils.PadZeros(strconv.FormatUint(hi, 10), width)
+		}
+		ranges = append(ranges, r)
+		return true
+	})
+
+	if len(ranges) == 1 && !strings.Contains(ranges[0], "-") {
+		return ranges[0]
+	}
+	return "[" + strings.Join(ranges, ",") + "]"
+}
+
+// filterBitmap returns a new bitmap containing the values of b for which keep returns true
+func filterBitmap(b *bitmap.Bitmap, keep func(v uint64) bool) *bitmap.Bitmap {
+	result := bitmap.New()
+	b.ForEach(func(v uint64) bool {
+		if keep(v) {
+			result.Add(v)
+		}
+		return true
+	})
+	return result
+}
+
+// numDigits returns the number of decimal digits of v
+func numDigits(v uint64) int {
+	n := 1
+	for ; v >= 10; v /= 10 {
+		n++
+	}
+	return n
+}
@@ -0,0 +1,141 @@
+package hostlist_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+	"github.com/puttsk/hostlist/utils"
+)
+
+type HostSetTestcase struct {
+	Hostlist       []string
+	ExpectedResult string
+}
+
+var HostSetTestcases = []HostSetTestcase{
+	{
+		Hostlist:       []string{},
+		ExpectedResult: "",
+	},
+	{
+		Hostlist:       []string{"node3", "node1", "node2", "node2", "node5"},
+		ExpectedResult: "node[1-3,5]",
+	},
+	{
+		Hostlist:       []string{"node09", "node10", "node11", "node1", "login"},
+		ExpectedResult: "login,node1,node[09-11]",
+	},
+	{
+		Hostlist:       []string{"rack1-node01", "rack1-node02", "rack2-node01", "rack10-node01"},
+		ExpectedResult: "rack1-node[01-02],rack[2,10]-node01",
+	},
+	{
+		Hostlist:       []string{"rack2-node2", "rack1-node1", "rack2-node1", "rack1-node2", "rack3-node1"},
+		ExpectedResult: "rack[1-2]-node[1-2],rack3-node1",
+	},
+	{
+		Hostlist:       []string{"r01-n1-a", "r01-n2-a", "r02-n1-a", "r02-n2-a", "r1-n1-a", "r99999999999999999999999-n1-a"},
+		ExpectedResult: "r[01-02]-n[1-2]-a,r1-n1-a,r99999999999999999999999-n1-a",
+	},
+	{
+		Hostlist:       []string{"a1b", "a2b", "a3c", "n99999999999999999999999"},
+		ExpectedResult: "a[1-2]b,a3c,n99999999999999999999999",
+	},
+	{
+		Hostlist:       []string{"n000", "n00", "n0", "n1"},
+		ExpectedResult: "n[0-1],n00,n000",
+	},
+	{
+		Hostlist:       []string{"n0", "n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10"},
+		ExpectedResult: "n[0-9,10]",
+	},
+	{
+		Hostlist:       []string{"n0", "n1", "n2", "n3", "n4", "n5", "n6", "n7", "n8", "n9", "n10", "n11", "n12"},
+		ExpectedResult: "n[0-9,10-12]",
+	},
+}
+
+// TestHostSet tests hostlist.HostSet
+func TestHostSet(t *testing.T) {
+	for _, c := range HostSetTestcases {
+		t.Logf("Testcase: %v\n", c.Hostlist)
+		s := hostlist.NewHostSet(c.Hostlist...)
+		if s.String() != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual: %s expect: %s", s.String(), c.ExpectedResult)
+		}
+
+		for _, h := range c.Hostlist {
+			if !s.Contains(h) {
+				t.Fatalf("Hostname not found: %s", h)
+			}
+		}
+
+		// The expression is expanded to the same hostnames
+		if len(c.Hostlist) > 0 {
+			hosts, err := hostlist.Expand(s.String())
+			if err != nil {
+				t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+			}
+			slices.SortFunc(hosts, utils.NaturalCompare)
+			if !reflect.DeepEqual(hosts, s.Hosts()) {
+				t.Fatalf("Invalid hostnames: actual: %v expect: %v", hosts, s.Hosts())
+			}
+			if s.Len() != len(hosts) {
+				t.Fatalf("Invalid length: actual: %d expect: %d", s.Len(), len(hosts))
+			}
+		}
+
+		for _, h := range c.Hostlist {
+			s.Remove(h)
+		}
+		if s.Len() != 0 || s.String() != "" {
+			t.Fatalf("Invalid set after remove: %s", s)
+		}
+	}
+}
+
+// TestParseHostSet tests that an expression with several ranges is rendered as one expression
+func TestParseHostSet(t *testing.T) {
+	for _, expression := range []string{"rack[1-4]-node[01-16]", "r[1-2].[0-9,10].[1-3]a", "rack[1-2,4]-node[01-16]-gpu[0-3]"} {
+		t.Logf("Testcase: %s\n", expression)
+		s, err := hostlist.ParseHostSet(expression)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if s.String() != expression {
+			t.Fatalf("Invalid expression: actual: %s expect: %s", s.String(), expression)
+		}
+	}
+}
+
+// TestHostSetOperations tests union, intersection, and difference of hostlist.HostSet
+func TestHostSetOperations(t *testing.T) {
+	idle, err := hostlist.ParseHostSet("node[001-100],login[1-2]")
+	if err != nil {
+		t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+	}
+	down, _ := hostlist.ParseHostSet("node[050-150],login2,gpu1")
+
+	testcases := []struct {
+		Name           string
+		Set            *hostlist.HostSet
+		ExpectedResult string
+	}{
+		{Name: "union", Set: idle.Union(down), ExpectedResult: "gpu1,login[1-2],node[001-150]"},
+		{Name: "intersect", Set: idle.Intersect(down), ExpectedResult: "login2,node[050-100]"},
+		{Name: "difference", Set: idle.Difference(down), ExpectedResult: "login1,node[001-049]"},
+		{Name: "empty", Set: (&hostlist.HostSet{}).Union(&hostlist.HostSet{}), ExpectedResult: ""},
+	}
+	for _, c := range testcases {
+		t.Logf("Testcase: %s\n", c.Name)
+		if c.Set.String() != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual: %s expect: %s", c.Set.String(), c.ExpectedResult)
+		}
+	}
+
+	if _, err := hostlist.ParseHostSet("node[1-"); err == nil {
+		t.Fatalf("Invalid error: actual: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package hostlist
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Hostlist represents a list of hostnames which is stored as a hostlist expression in text formats,
+// e.g., JSON, YAML, or TOML. Hostlist implements encoding.TextMarshaler, encoding.TextUnmarshaler,
+// json.Marshaler, and json.Unmarshaler.
+//
+// The hostnames are compressed with Fold, so the expression can always be expanded, and
+// expanded with Expand. Duplicated hostnames are removed and the hostnames are sorted in
+// natural order after a round trip.
+//
+// For example:
+//
+//	Hostlist{"node1", "node2", "node3"} is encoded in JSON as `"node[1-3]"`
+type Hostlist []string
+
+// String returns the hostlist expression of the list
+func (h Hostlist) String() string {
+	expr, _ := Fold(h)
+	return expr
+}
+
+// MarshalText returns the hostlist expression of the list
+func (h Hostlist) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// UnmarshalText expands a hostlist expression into the list. An empty text results in an empty list.
+func (h *Hostlist) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*h = Hostlist{}
+		return nil
+	}
+
+	hosts, err := Expand(string(text))
+	if err != nil {
+		return err
+	}
+	*h = hosts
+	return nil
+}
+
+// MarshalJSON returns the hostlist expression of the list as a JSON string
+func (h Hostlist) MarshalJSON() ([]byte, error) {
+	return json.Marshal(h.String())
+}
+
+// UnmarshalJSON expands a JSON string containing a hostlist expression into the list.
+// A JSON array is also accepted, and each element of the array is expanded as a hostlist
+// expression, e.g., `["node[1-2]", "login"]`. Empty expressions are ignored.
+// A JSON null does not modify the list.
+func (h *Hostlist) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if len(data) > 0 && data[0] == '[' {
+		expressions := []string{}
+		if err := json.Unmarshal(data, &expressions); err != nil {
+			return err
+		}
+
+		hosts := Hostlist{}
+		for _, expr := range expressions {
+			if expr == "" {
+				continue
+			}
+			var err error
+			if hosts, err = AppendExpand(hosts, expr); err != nil {
+				return err
+			}
+		}
+		*h = hosts
+		return nil
+	}
+
+	var expr string
+	if err := json.Unmarshal(data, &expr); err != nil {
+		return err
+	}
+	return h.UnmarshalText([]byte(expr))
+}
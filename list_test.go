@@ -0,0 +1,102 @@
+package hostlist_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+	"github.com/puttsk/hostlist/expand"
+)
+
+type HostlistJSONTestcase struct {
+	JSON           string
+	ExpectedResult hostlist.Hostlist
+	ExpectedError  bool
+}
+
+var HostlistJSONTestcases = []HostlistJSONTestcase{
+	{
+		JSON:           `{"nodes": "node[1-3]"}`,
+		ExpectedResult: hostlist.Hostlist{"node1", "node2", "node3"},
+	},
+	{
+		JSON:           `{"nodes": ""}`,
+		ExpectedResult: hostlist.Hostlist{},
+	},
+	{
+		JSON:           `{"nodes": ["node[1-2]", "login", ""]}`,
+		ExpectedResult: hostlist.Hostlist{"node1", "node2", "login"},
+	},
+	{
+		JSON:           `{"nodes": null}`,
+		ExpectedResult: nil,
+	},
+	{
+		JSON:          `{"nodes": "node[1-"}`,
+		ExpectedError: true,
+	},
+	{
+		JSON:          `{"nodes": ["node[1-2]", 3]}`,
+		ExpectedError: true,
+	},
+	{
+		JSON:          `{"nodes": 3}`,
+		ExpectedError: true,
+	},
+}
+
+type hostlistConfig struct {
+	Nodes hostlist.Hostlist `json:"nodes"`
+}
+
+// TestHostlistUnmarshalJSON tests hostlist.Hostlist.UnmarshalJSON
+func TestHostlistUnmarshalJSON(t *testing.T) {
+	for _, c := range HostlistJSONTestcases {
+		t.Logf("Testcase: %s\n", c.JSON)
+		config := hostlistConfig{}
+		err := json.Unmarshal([]byte(c.JSON), &config)
+		if (err != nil) != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %v expected error: %v", err, c.ExpectedError)
+		}
+		if err == nil && !reflect.DeepEqual(config.Nodes, c.ExpectedResult) {
+			t.Fatalf("Invalid hostnames: actual: %#v expect: %#v", config.Nodes, c.ExpectedResult)
+		}
+	}
+}
+
+// TestHostlistMarshal tests encoding hostlist.Hostlist as JSON and text
+func TestHostlistMarshal(t *testing.T) {
+	config := hostlistConfig{Nodes: hostlist.Hostlist{"rack1-node2", "rack1-node1", "rack2-node1", "rack2-node2", "login"}}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+	}
+	expected := `{"nodes":"login,rack[1-2]-node[1-2]"}`
+	if string(data) != expected {
+		t.Fatalf("Invalid JSON: actual: %s expect: %s", data, expected)
+	}
+
+	// Round trip
+	decoded := hostlistConfig{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+	}
+	expectedHosts := hostlist.Hostlist{"login", "rack1-node1", "rack1-node2", "rack2-node1", "rack2-node2"}
+	if !reflect.DeepEqual(decoded.Nodes, expectedHosts) {
+		t.Fatalf("Invalid hostnames: actual: %v expect: %v", decoded.Nodes, expectedHosts)
+	}
+
+	text, _ := hostlist.Hostlist{}.MarshalText()
+	if string(text) != "" {
+		t.Fatalf("Invalid text: actual: %s expect: %s", text, "")
+	}
+
+	var h hostlist.Hostlist
+	if err := h.UnmarshalText([]byte("node[01-02]")); err != nil || !reflect.DeepEqual(h, hostlist.Hostlist{"node01", "node02"}) {
+		t.Fatalf("Invalid hostnames: actual: %v %v", h, err)
+	}
+	if err := h.UnmarshalText([]byte("node[")); err != expand.ErrExpectedCloseBracket {
+		t.Fatalf("Invalid error: actual: %v expected: %s", err, expand.ErrExpectedCloseBracket)
+	}
+}
@@ -0,0 +1,37 @@
+package hostlist
+
+import "github.com/puttsk/hostlist/expand"
+
+// Matcher checks whether hostnames belong to a hostlist expression without expanding it.
+// Each part of a single expression is matched at most once at each position of the hostname,
+// and the values of a range expression are found through an index, so the time to match
+// a hostname grows with the length of the hostname and the number of parts of the expression,
+// not with the number of hostnames in the expression. A Matcher is safe for concurrent use.
+type Matcher struct {
+	patterns []*expand.Pattern
+}
+
+// NewMatcher returns a Matcher for a hostlist expression
+//
+// For example:
+//
+//	m, _ := NewMatcher("node[0001-9999],login[1-2]")
+//	m.Match("node0042") // true
+//	m.Match("node42")   // false
+func NewMatcher(expression string) (*Matcher, error) {
+	patterns, err := splitPatterns(expression)
+	if err != nil {
+		return nil, err
+	}
+	return &Matcher{patterns: patterns}, nil
+}
+
+// Match returns true if host is one of the hostnames of the expression
+func (m *Matcher) Match(host string) bool {
+	for _, p := range m.patterns {
+		if p.Match(host) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,153 @@
+package hostlist_test
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/puttsk/hostlist"
+)
+
+type MatcherTestcase struct {
+	HostlistExpression string
+	Match              []string
+	NoMatch            []string
+}
+
+var MatcherTestcases = []MatcherTestcase{
+	{
+		HostlistExpression: "node[1-4]",
+		Match:              []string{"node1", "node4"},
+		NoMatch:            []string{"node0", "node5", "node01", "node", "node1a", "xnode1", ""},
+	},
+	{
+		HostlistExpression: "node[001-100]",
+		Match:              []string{"node001", "node042", "node100"},
+		NoMatch:            []string{"node1", "node42", "node000", "node101", "node0042"},
+	},
+	{
+		HostlistExpression: "rack[1-12]-node[08-11],login",
+		Match:              []string{"rack1-node08", "rack12-node11", "login"},
+		NoMatch:            []string{"rack0-node08", "rack13-node08", "rack1-node8", "rack01-node09", "login1"},
+	},
+	{
+		HostlistExpression: "a[1-2]1[0-9]",
+		Match:              []string{"a110", "a219"},
+		NoMatch:            []string{"a11", "a1110", "a310"},
+	},
+	{
+		HostlistExpression: "n[1-99999999999999999999999]",
+		Match:              []string{"n1", "n12345678901234567890", "n99999999999999999999999"},
+		NoMatch:            []string{"n0", "n100000000000000000000000", "n01"},
+	},
+	{
+		HostlistExpression: "h[a,1-3,b-c]x",
+		Match:              []string{"hax", "h2x", "hb-cx"},
+		NoMatch:            []string{"hbx", "h4x", "hx"},
+	},
+}
+
+// TestMatcher tests hostlist.Matcher
+func TestMatcher(t *testing.T) {
+	for _, c := range MatcherTestcases {
+		t.Logf("Testcase: %s\n", c.HostlistExpression)
+		m, err := hostlist.NewMatcher(c.HostlistExpression)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		for _, h := range c.Match {
+			if !m.Match(h) {
+				t.Fatalf("Invalid match: %s should match", h)
+			}
+		}
+		for _, h := range c.NoMatch {
+			if m.Match(h) {
+				t.Fatalf("Invalid match: %s should not match", h)
+			}
+		}
+	}
+
+	if _, err := hostlist.NewMatcher("node[1-"); err == nil {
+		t.Fatalf("Invalid error: actual: %v", err)
+	}
+}
+
+// TestMatcherExpand checks that hostlist.Matcher matches exactly the hostnames of hostlist.Expand
+func TestMatcherExpand(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, expr := range []string{
+		"n[0-12]", "n[00-12]-a[7-10]", "n[5-7,9,010-012]", "n[1-3]0[8-9],a",
+		"n[20-25,1-5,3-9,12]", "n[0-3,08-11,5,05]", "n[1,01,001][1-2]", "[1-9][0-9][1-99]",
+	} {
+		t.Logf("Testcase: %s\n", expr)
+		hosts, _ := hostlist.Expand(expr)
+		expected := map[string]bool{}
+		for _, h := range hosts {
+			expected[h] = true
+		}
+		m, _ := hostlist.NewMatcher(expr)
+
+		for i := 0; i < 10000; i++ {
+			host := mutateHost(r, hosts[r.Intn(len(hosts))])
+			if m.Match(host) != expected[host] {
+				t.Fatalf("Invalid match: %s: actual: %v expect: %v", host, m.Match(host), expected[host])
+			}
+		}
+	}
+}
+
+// TestMatcherPathological checks that matching does not backtrack exponentially on adjacent
+// numeric ranges, and does not check each element of a range expression with many elements
+func TestMatcherPathological(t *testing.T) {
+	elements := make([]string, 200000)
+	for i := range elements {
+		elements[i] = strconv.Itoa(2 * i)
+	}
+
+	for _, c := range []struct {
+		HostlistExpression string
+		Host               string
+	}{
+		{HostlistExpression: strings.Repeat("[1-999]", 24), Host: strings.Repeat("1", 3000) + "x"},
+		{HostlistExpression: strings.Repeat("[1-999]", 24), Host: strings.Repeat("9", 72)},
+		{HostlistExpression: "n[" + strings.Join(elements, ",") + "]", Host: "n399999"},
+	} {
+		t.Logf("Testcase: %.40s\n", c.HostlistExpression)
+		m, err := hostlist.NewMatcher(c.HostlistExpression)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		m.Match(c.Host) // Build the index
+
+		start := time.Now()
+		for i := 0; i < 100; i++ {
+			m.Match(c.Host)
+		}
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("Invalid match time: actual: %s expect: less than %s", elapsed, time.Second)
+		}
+	}
+}
+
+// mutateHost randomly inserts, removes, or replaces up to 2 characters of a hostname
+func mutateHost(r *rand.Rand, hostname string) string {
+	alphabet := "0123456789-an"
+	host := []byte(hostname)
+	for j := r.Intn(3); j > 0; j-- {
+		switch pos := r.Intn(len(host) + 1); r.Intn(3) {
+		case 0:
+			host = append(host[:pos], append([]byte{alphabet[r.Intn(len(alphabet))]}, host[pos:]...)...)
+		case 1:
+			if pos < len(host) {
+				host = append(host[:pos], host[pos+1:]...)
+			}
+		default:
+			if pos < len(host) {
+				host[pos] = alphabet[r.Intn(len(alphabet))]
+			}
+		}
+	}
+	return string(host)
+}
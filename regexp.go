@@ -0,0 +1,46 @@
+package hostlist
+
+import "strings"
+
+// ToRegexp returns an anchored regular expression matching exactly the hostnames of a hostlist
+// expression. The regular expression uses the RE2 syntax accepted by package regexp, and
+// numeric ranges are converted to alternations of digit ranges respecting their zero padding.
+//
+// For example:
+//
+//	`node[08-11]` will be converted to `^node(?:0[8-9]|1[0-1])$`
+func ToRegexp(expression string) (string, error) {
+	patterns, err := splitPatterns(expression)
+	if err != nil {
+		return "", err
+	}
+
+	alternatives := make([]string, len(patterns))
+	for i, p := range patterns {
+		alternatives[i] = p.Regexp()
+	}
+	if len(alternatives) == 1 {
+		return "^" + alternatives[0] + "$", nil
+	}
+	return "^(?:" + strings.Join(alternatives, "|") + ")$", nil
+}
+
+// ToGlob returns a shell glob pattern for each single expression of a hostlist expression.
+// ToGlob is best effort: a range expression which cannot be represented by character classes
+// is replaced with `*`, so the globs can match more hostnames than the expression.
+//
+// For example:
+//
+//	`node[001-100],login[1-2]` will be converted to `["node[0-1][0-9][0-9]", "login[1-2]"]`
+func ToGlob(expression string) ([]string, error) {
+	patterns, err := splitPatterns(expression)
+	if err != nil {
+		return nil, err
+	}
+
+	globs := make([]string, len(patterns))
+	for i, p := range patterns {
+		globs[i] = p.Glob()
+	}
+	return globs, nil
+}
@@ -0,0 +1,115 @@
+package hostlist_test
+
+import (
+	"math/rand"
+	"path"
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+)
+
+type ToRegexpTestcase struct {
+	HostlistExpression string
+	ExpectedResult     string
+	ExpectedGlob       []string
+}
+
+var ToRegexpTestcases = []ToRegexpTestcase{
+	{
+		HostlistExpression: "node[08-11]",
+		ExpectedResult:     `^node(?:0[8-9]|1[0-1])$`,
+		ExpectedGlob:       []string{"node[0-1][0-9]"},
+	},
+	{
+		HostlistExpression: "node[1-100]",
+		ExpectedResult:     `^node(?:[1-9]|[1-9][0-9]|100)$`,
+		ExpectedGlob:       []string{"node*"},
+	},
+	{
+		HostlistExpression: "n[001-100].cluster,login[1-2]",
+		ExpectedResult:     `^(?:n(?:0(?:0[1-9]|[1-9][0-9])|100)\.cluster|login[1-2])$`,
+		ExpectedGlob:       []string{"n[0-1][0-9][0-9].cluster", "login[1-2]"},
+	},
+	{
+		HostlistExpression: "r[1-3,a]-n[7,b,0-3]",
+		ExpectedResult:     `^r(?:[1-3]|a)-n(?:7|b|[0-3])$`,
+		ExpectedGlob:       []string{"r[1-3a]-n[7b0-3]"},
+	},
+	{
+		HostlistExpression: "h[1234-5678]",
+		ExpectedResult:     `^h(?:1(?:2(?:3[4-9]|[4-9][0-9])|[3-9][0-9]{2})|[2-4][0-9]{3}|5(?:[0-5][0-9]{2}|6(?:[0-6][0-9]|7[0-8])))$`,
+		ExpectedGlob:       []string{"h[1-5][0-9][0-9][0-9]"},
+	},
+	{
+		HostlistExpression: "x[a,-,z]",
+		ExpectedResult:     `^x(?:a|-|z)$`,
+		ExpectedGlob:       []string{"x*"},
+	},
+}
+
+// TestToRegexp tests hostlist.ToRegexp and hostlist.ToGlob
+func TestToRegexp(t *testing.T) {
+	for _, c := range ToRegexpTestcases {
+		t.Logf("Testcase: %s\n", c.HostlistExpression)
+		expr, err := hostlist.ToRegexp(c.HostlistExpression)
+		if err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		if expr != c.ExpectedResult {
+			t.Fatalf("Invalid regexp: actual: %s expect: %s", expr, c.ExpectedResult)
+		}
+
+		globs, _ := hostlist.ToGlob(c.HostlistExpression)
+		if !reflect.DeepEqual(globs, c.ExpectedGlob) {
+			t.Fatalf("Invalid glob: actual: %v expect: %v", globs, c.ExpectedGlob)
+		}
+	}
+
+	if _, err := hostlist.ToRegexp("node[1-"); err == nil {
+		t.Fatalf("Invalid error: actual: %v", err)
+	}
+}
+
+// TestToRegexpExpand checks that the regular expression of hostlist.ToRegexp matches exactly
+// the hostnames of hostlist.Expand, and the globs of hostlist.ToGlob match all of them
+func TestToRegexpExpand(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	for _, expr := range []string{
+		"n[0-12]", "n[00-12]-a[7-10]", "n[5-7,9,010-012]", "n[1-3]0[8-9],a",
+		"n[1-1000]", "n[0001-1234]", "n[95-1005]", "n[199-301],n[0-0]", "n[8-9][10-11]", "x[a,-,z]", "x[-,1-3]",
+	} {
+		t.Logf("Testcase: %s\n", expr)
+		hosts, _ := hostlist.Expand(expr)
+		expected := map[string]bool{}
+		for _, h := range hosts {
+			expected[h] = true
+		}
+
+		re, _ := hostlist.ToRegexp(expr)
+		globs, _ := hostlist.ToGlob(expr)
+		m := regexp.MustCompile(re)
+		for _, h := range hosts {
+			if !m.MatchString(h) {
+				t.Fatalf("Invalid match: %s should match %s", h, re)
+			}
+			matched := false
+			for _, g := range globs {
+				if ok, _ := path.Match(g, h); ok {
+					matched = true
+				}
+			}
+			if !matched {
+				t.Fatalf("Invalid glob: %s should match %v", h, globs)
+			}
+		}
+
+		for i := 0; i < 10000; i++ {
+			host := mutateHost(r, hosts[r.Intn(len(hosts))])
+			if m.MatchString(host) != expected[host] {
+				t.Fatalf("Invalid match: %s: actual: %v expect: %v", host, m.MatchString(host), expected[host])
+			}
+		}
+	}
+}
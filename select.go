@@ -0,0 +1,89 @@
+package hostlist
+
+import (
+	"path"
+	"regexp"
+
+	"github.com/puttsk/hostlist/compress"
+)
+
+// Inventory is a source of hostnames for Select, SelectRegexp, and SelectFunc.
+// Hostlist, Expression, and *HostSet implement Inventory.
+type Inventory interface {
+	// ForEachHost calls fn for each hostname until fn returns false
+	ForEachHost(fn func(host string) bool) error
+}
+
+// Expression is a hostlist expression used as an Inventory. The expression is expanded
+// while the hostnames are selected.
+type Expression string
+
+// ForEachHost calls fn for each hostname of the expression until fn returns false.
+// An error is returned if the expression is invalid.
+func (e Expression) ForEachHost(fn func(host string) bool) error {
+	return ExpandFunc(string(e), func(host []byte) bool {
+		return fn(string(host))
+	})
+}
+
+// ForEachHost calls fn for each hostname of the list until fn returns false
+func (h Hostlist) ForEachHost(fn func(host string) bool) error {
+	for _, host := range h {
+		if !fn(host) {
+			break
+		}
+	}
+	return nil
+}
+
+// ForEachHost calls fn for each hostname of the set in natural order until fn returns false
+func (s *HostSet) ForEachHost(fn func(host string) bool) error {
+	for _, host := range s.Hosts() {
+		if !fn(host) {
+			break
+		}
+	}
+	return nil
+}
+
+// Select returns a hostlist expression of the hostnames in inventory matching a shell glob pattern.
+// The pattern uses the syntax of path.Match, and path.ErrBadPattern is returned if it is malformed.
+//
+// For example:
+//
+//	Select(Expression("gpu-[1-4]-[a100,h100]"), "gpu-*-a100") returns `gpu-[1-4]-a100`
+func Select(inventory Inventory, pattern string) (string, error) {
+	// Check the pattern before matching hostnames
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", err
+	}
+	return SelectFunc(inventory, func(host string) bool {
+		matched, _ := path.Match(pattern, host)
+		return matched
+	})
+}
+
+// SelectRegexp returns a hostlist expression of the hostnames in inventory matching regular expression re.
+// The regular expression is not anchored, use `^` and `$` to match whole hostnames.
+func SelectRegexp(inventory Inventory, re *regexp.Regexp) (string, error) {
+	return SelectFunc(inventory, re.MatchString)
+}
+
+// SelectFunc returns a hostlist expression of the hostnames in inventory for which keep returns true.
+// The hostnames are compressed with the expression tree, like Compress.
+func SelectFunc(inventory Inventory, keep func(host string) bool) (string, error) {
+	selected := []string{}
+	err := inventory.ForEachHost(func(host string) bool {
+		if keep(host) {
+			selected = append(selected, host)
+		}
+		return true
+	})
+	if err != nil {
+		return "", err
+	}
+
+	tree := compress.NewHostlistExpressionTree()
+	tree.AddHosts(selected)
+	return tree.GetExpression(), nil
+}
@@ -0,0 +1,84 @@
+package hostlist_test
+
+import (
+	"path"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+)
+
+type SelectTestcase struct {
+	Name           string
+	Inventory      hostlist.Inventory
+	Pattern        string
+	ExpectedResult string
+	ExpectedError  error
+}
+
+var SelectTestcases = []SelectTestcase{
+	{
+		Name:           "expression",
+		Inventory:      hostlist.Expression("gpu-[1-4]-[a100,h100],cpu-[1-8]"),
+		Pattern:        "gpu-*-a100",
+		ExpectedResult: "gpu-[1-4]-a100",
+	},
+	{
+		Name:           "slice",
+		Inventory:      hostlist.Hostlist{"node3", "node1", "login1", "node2", "node10"},
+		Pattern:        "node?",
+		ExpectedResult: "node[1-3]",
+	},
+	{
+		Name:           "host set",
+		Inventory:      hostlist.NewHostSet("rack1-node1", "rack1-node2", "rack2-node1"),
+		Pattern:        "rack[2-9]-*",
+		ExpectedResult: "rack2-node1",
+	},
+	{
+		Name:           "no match",
+		Inventory:      hostlist.Expression("node[1-4]"),
+		Pattern:        "login*",
+		ExpectedResult: "",
+	},
+	{
+		Name:          "invalid pattern",
+		Inventory:     hostlist.Expression("node[1-4]"),
+		Pattern:       "node[",
+		ExpectedError: path.ErrBadPattern,
+	},
+}
+
+// TestSelect tests hostlist.Select
+func TestSelect(t *testing.T) {
+	for _, c := range SelectTestcases {
+		t.Logf("Testcase: %s\n", c.Name)
+		expr, err := hostlist.Select(c.Inventory, c.Pattern)
+		if err != c.ExpectedError {
+			t.Fatalf("Invalid error: actual: %v expected: %v", err, c.ExpectedError)
+		}
+		if expr != c.ExpectedResult {
+			t.Fatalf("Invalid expression: actual: %s expect: %s", expr, c.ExpectedResult)
+		}
+	}
+
+	if _, err := hostlist.Select(hostlist.Expression("node[1-"), "*"); err == nil {
+		t.Fatalf("Invalid error: actual: %v", err)
+	}
+}
+
+// TestSelectRegexp tests hostlist.SelectRegexp and hostlist.SelectFunc
+func TestSelectRegexp(t *testing.T) {
+	inventory := hostlist.Expression("node[001-020],login[1-2]")
+
+	expr, err := hostlist.SelectRegexp(inventory, regexp.MustCompile(`^node0[01][05]$`))
+	if err != nil || expr != "node[005,010,015]" {
+		t.Fatalf("Invalid expression: actual: %s %v expect: %s", expr, err, "node[005,010,015]")
+	}
+
+	expr, err = hostlist.SelectFunc(inventory, func(host string) bool { return !strings.HasPrefix(host, "node") })
+	if err != nil || expr != "login[1-2]" {
+		t.Fatalf("Invalid expression: actual: %s %v expect: %s", expr, err, "login[1-2]")
+	}
+}
@@ -0,0 +1,30 @@
+package hostlist
+
+import (
+	"database/sql/driver"
+	"fmt"
+)
+
+// Scan expands a hostlist expression stored in a database column into the list.
+// Scan implements sql.Scanner. A NULL value results in a nil list.
+func (h *Hostlist) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case string:
+		return h.UnmarshalText([]byte(v))
+	case []byte:
+		return h.UnmarshalText(v)
+	}
+	return fmt.Errorf("hostlist: cannot scan %T into Hostlist", src)
+}
+
+// Value returns the hostlist expression of the list for storing in a database column.
+// Value implements driver.Valuer. A nil list is stored as NULL.
+func (h Hostlist) Value() (driver.Value, error) {
+	if h == nil {
+		return nil, nil
+	}
+	return h.String(), nil
+}
@@ -0,0 +1,128 @@
+package hostlist_test
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/puttsk/hostlist"
+)
+
+// memoryDriver is a database driver storing the values of a single column in memory.
+// `INSERT` appends its argument to the column, and `SELECT` returns all values.
+type memoryDriver struct {
+	values []driver.Value
+}
+
+func (d *memoryDriver) Open(name string) (driver.Conn, error) { return &memoryConn{d}, nil }
+
+type memoryConn struct{ driver *memoryDriver }
+
+func (c *memoryConn) Prepare(query string) (driver.Stmt, error) {
+	return &memoryStmt{c.driver, query}, nil
+}
+func (c *memoryConn) Close() error              { return nil }
+func (c *memoryConn) Begin() (driver.Tx, error) { return nil, driver.ErrSkip }
+
+type memoryStmt struct {
+	driver *memoryDriver
+	query  string
+}
+
+func (s *memoryStmt) Close() error { return nil }
+func (s *memoryStmt) NumInput() int {
+	if s.query == "INSERT" {
+		return 1
+	}
+	return 0
+}
+func (s *memoryStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.driver.values = append(s.driver.values, args[0])
+	return driver.RowsAffected(1), nil
+}
+func (s *memoryStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return &memoryRows{values: s.driver.values}, nil
+}
+
+type memoryRows struct {
+	values []driver.Value
+}
+
+func (r *memoryRows) Columns() []string { return []string{"nodelist"} }
+func (r *memoryRows) Close() error      { return nil }
+func (r *memoryRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	dest[0], r.values = r.values[0], r.values[1:]
+	return nil
+}
+
+var memory = &memoryDriver{}
+
+func init() {
+	sql.Register("hostlist-memory", memory)
+}
+
+// TestHostlistSQL stores hostlist.Hostlist in a database and reads it back
+func TestHostlistSQL(t *testing.T) {
+	// The driver is registered once, so the values stored by previous runs are removed
+	memory.values = nil
+
+	db, err := sql.Open("hostlist-memory", "")
+	if err != nil {
+		t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+	}
+	defer db.Close()
+
+	inputs := []hostlist.Hostlist{
+		{"node1", "node2", "node3"},
+		{},
+		nil,
+		{"rack1-node01", "rack1-node02", "login"},
+	}
+	for _, h := range inputs {
+		if _, err := db.Exec("INSERT", h); err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+	}
+
+	expectedValues := []driver.Value{"node[1-3]", "", nil, "login,rack1-node[01-02]"}
+	if !reflect.DeepEqual(memory.values, expectedValues) {
+		t.Fatalf("Invalid stored values: actual: %#v expect: %#v", memory.values, expectedValues)
+	}
+
+	rows, err := db.Query("SELECT")
+	if err != nil {
+		t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+	}
+	defer rows.Close()
+
+	expected := []hostlist.Hostlist{
+		{"node1", "node2", "node3"},
+		{},
+		nil,
+		{"login", "rack1-node01", "rack1-node02"},
+	}
+	results := []hostlist.Hostlist{}
+	for rows.Next() {
+		var h hostlist.Hostlist
+		if err := rows.Scan(&h); err != nil {
+			t.Fatalf("Invalid error: actual: %s expected: %v", err, nil)
+		}
+		results = append(results, h)
+	}
+	if !reflect.DeepEqual(results, expected) {
+		t.Fatalf("Invalid hostnames: actual: %#v expect: %#v", results, expected)
+	}
+
+	var h hostlist.Hostlist
+	if err := h.Scan(42); err == nil {
+		t.Fatalf("Invalid error: actual: %v", err)
+	}
+	if err := h.Scan([]byte("node[")); err == nil {
+		t.Fatalf("Invalid error: actual: %v", err)
+	}
+}
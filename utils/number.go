@@ -0,0 +1,123 @@
+package utils
+
+import (
+	"strings"
+)
+
+// TrimZeros removes leading zeroes from a decimal number.
+// The number zero is returned as `0`.
+//
+// For example:
+//
+//	`007` will be converted to `7`
+//	`000` will be converted to `0`
+func TrimZeros(number string) string {
+	trimmed := strings.TrimLeft(number, "0")
+	if trimmed == "" && number != "" {
+		return "0"
+	}
+	return trimmed
+}
+
+// PadZeros adds leading zeroes to a decimal number until it has at least `width` digits
+//
+// For example:
+//
+//	`7` with width 3 will be converted to `007`
+func PadZeros(number string, width int) string {
+	if len(number) >= width {
+		return number
+	}
+	return strings.Repeat("0", width-len(number)) + number
+}
+
+// CompareNumbers compares two decimal numbers of arbitrary length by their values, ignoring
+// leading zeroes. The result will be 0 if a == b, -1 if a < b, and +1 if a > b.
+//
+// For example:
+//
+//	CompareNumbers("9", "10") is -1
+//	CompareNumbers("010", "10") is 0
+func CompareNumbers(a, b string) int {
+	a, b = TrimZeros(a), TrimZeros(b)
+	if len(a) != len(b) {
+		if len(a) < len(b) {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(a, b)
+}
+
+// IncrementNumber returns a decimal number of arbitrary length increased by one.
+// Leading zeroes are kept as long as the number fits in the same width.
+//
+// For example:
+//
+//	`009` will be converted to `010`
+//	`99` will be converted to `100`
+func IncrementNumber(number string) string {
+	digits := []byte(number)
+	for i := len(digits) - 1; i >= 0; i-- {
+		if digits[i] < '9' {
+			digits[i]++
+			return string(digits)
+		}
+		digits[i] = '0'
+	}
+	return "1" + string(digits)
+}
+
+// NaturalCompare compares two strings in natural order: runs of ASCII digits are compared
+// by their numeric values, and other characters are compared byte by byte. Numbers with the
+// same value are ordered by their zero padding, e.g., `01` comes before `1`.
+// The result will be 0 if a == b, -1 if a < b, and +1 if a > b.
+//
+// For example:
+//
+//	NaturalCompare("node9", "node10") is -1
+//	NaturalCompare("node10", "node-10") is +1
+func NaturalCompare(a, b string) int {
+	i, j := 0, 0
+	for i < len(a) && j < len(b) {
+		if isDigit(a[i]) && isDigit(b[j]) {
+			// Compare the whole number
+			endA, endB := i, j
+			for endA < len(a) && isDigit(a[endA]) {
+				endA++
+			}
+			for endB < len(b) && isDigit(b[endB]) {
+				endB++
+			}
+			if c := CompareNumbers(a[i:endA], b[j:endB]); c != 0 {
+				return c
+			}
+			if c := strings.Compare(a[i:endA], b[j:endB]); c != 0 {
+				return c
+			}
+			i, j = endA, endB
+			continue
+		}
+
+		if a[i] != b[j] {
+			if a[i] < b[j] {
+				return -1
+			}
+			return 1
+		}
+		i++
+		j++
+	}
+
+	switch {
+	case i < len(a):
+		return 1
+	case j < len(b):
+		return -1
+	}
+	return 0
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
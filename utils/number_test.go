@@ -0,0 +1,90 @@
+package utils_test
+
+import (
+	"testing"
+
+	"github.com/puttsk/hostlist/utils"
+)
+
+type CompareNumbersTestcase struct {
+	A, B           string
+	ExpectedResult int
+}
+
+var CompareNumbersTestcases = []CompareNumbersTestcase{
+	{A: "0", B: "0", ExpectedResult: 0},
+	{A: "1", B: "2", ExpectedResult: -1},
+	{A: "9", B: "10", ExpectedResult: -1},
+	{A: "010", B: "10", ExpectedResult: 0},
+	{A: "000", B: "0", ExpectedResult: 0},
+	{A: "100", B: "099", ExpectedResult: 1},
+	{A: "123456789012345678901234567890", B: "123456789012345678901234567889", ExpectedResult: 1},
+	{A: "000123456789012345678", B: "123456789012345679", ExpectedResult: -1},
+}
+
+// TestCompareNumbers tests utils.CompareNumbers
+func TestCompareNumbers(t *testing.T) {
+	for _, c := range CompareNumbersTestcases {
+		t.Logf("Testcase: %s %s\n", c.A, c.B)
+		result := utils.CompareNumbers(c.A, c.B)
+		if result != c.ExpectedResult {
+			t.Fatalf("Invalid result: actual: %d expect: %d", result, c.ExpectedResult)
+		}
+	}
+}
+
+type IncrementNumberTestcase struct {
+	Number         string
+	ExpectedResult string
+}
+
+var IncrementNumberTestcases = []IncrementNumberTestcase{
+	{Number: "0", ExpectedResult: "1"},
+	{Number: "9", ExpectedResult: "10"},
+	{Number: "009", ExpectedResult: "010"},
+	{Number: "099", ExpectedResult: "100"},
+	{Number: "999", ExpectedResult: "1000"},
+	{Number: "18446744073709551615", ExpectedResult: "18446744073709551616"},
+	{Number: "000123456789012345678", ExpectedResult: "000123456789012345679"},
+}
+
+// TestIncrementNumber tests utils.IncrementNumber
+func TestIncrementNumber(t *testing.T) {
+	for _, c := range IncrementNumberTestcases {
+		t.Logf("Testcase: %s\n", c.Number)
+		result := utils.IncrementNumber(c.Number)
+		if result != c.ExpectedResult {
+			t.Fatalf("Invalid result: actual: %s expect: %s", result, c.ExpectedResult)
+		}
+	}
+}
+
+type NaturalCompareTestcase struct {
+	A, B           string
+	ExpectedResult int
+}
+
+var NaturalCompareTestcases = []NaturalCompareTestcase{
+	{A: "", B: "", ExpectedResult: 0},
+	{A: "", B: "a", ExpectedResult: -1},
+	{A: "node9", B: "node10", ExpectedResult: -1},
+	{A: "node10", B: "node9", ExpectedResult: 1},
+	{A: "node01", B: "node1", ExpectedResult: -1},
+	{A: "node1", B: "node1", ExpectedResult: 0},
+	{A: "node1", B: "node1a", ExpectedResult: -1},
+	{A: "node10", B: "node-10", ExpectedResult: 1},
+	{A: "10-a", B: "a", ExpectedResult: -1},
+	{A: "r2n10", B: "r10n1", ExpectedResult: -1},
+	{A: "n99999999999999999999", B: "n100000000000000000000", ExpectedResult: -1},
+}
+
+// TestNaturalCompare tests utils.NaturalCompare
+func TestNaturalCompare(t *testing.T) {
+	for _, c := range NaturalCompareTestcases {
+		t.Logf("Testcase: %s %s\n", c.A, c.B)
+		result := utils.NaturalCompare(c.A, c.B)
+		if result != c.ExpectedResult {
+			t.Fatalf("Invalid result: actual: %d expect: %d", result, c.ExpectedResult)
+		}
+	}
+}
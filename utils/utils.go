@@ -1,32 +1,111 @@
 package utils
 
+import "math"
+
 // CartesianProduct creates a list of Cartesian products from a set of arrays.
-// Returns an empty list if `a` is `nil` or empty list.
+// Returns an empty list if `a` is `nil` or empty list. The tuples do not share memory
+// with each other, so appending to a tuple does not modify other tuples.
 //
 // For example:
 //
 //	Cartesian product of `[[0,1]]` is [[0], [1]]
 //	Cartesian product of `[[0,1], [a,b]]` is [[0,a], [0,b], [1,a], [1,b]]
 func CartesianProduct[T any](a [][]T) [][]T {
-	// If 'a' is an empty array, return empty list
-	if len(a) == 0 {
-		return [][]T{}
+	p := NewProduct(a)
+	product := make([][]T, 0, p.Len())
+
+	// All tuples are stored in a single array. Each tuple is limited to its own part of the array.
+	values := make([]T, 0, p.Len()*len(a))
+	p.ForEach(func(tuple []T) bool {
+		start := len(values)
+		values = append(values, tuple...)
+		product = append(product, values[start:len(values):len(values)])
+		return true
+	})
+	return product
+}
+
+// Product represents the Cartesian product of a set of arrays without creating the tuples.
+// Tuples are ordered as in CartesianProduct, i.e., the last array changes the fastest.
+type Product[T any] struct {
+	lists [][]T
+	count int
+}
+
+// NewProduct creates a Product from a set of arrays. The arrays are not copied.
+// NewProduct panics if the number of tuples does not fit in int.
+func NewProduct[T any](a [][]T) *Product[T] {
+	count := 0
+	if len(a) > 0 {
+		count = 1
+		for _, list := range a {
+			if len(list) > 0 && count > math.MaxInt/len(list) {
+				panic("utils: number of tuples in Cartesian product overflows int")
+			}
+			count *= len(list)
+		}
 	}
+	return &Product[T]{lists: a, count: count}
+}
 
-	product := make([][]T, len(a[0]))
-	for i, v := range a[0] {
-		product[i] = []T{v}
+// Len returns the number of tuples in the product
+func (p *Product[T]) Len() int {
+	return p.count
+}
+
+// At returns the i-th tuple of the product. The tuple is written to dst if it has enough capacity,
+// otherwise a new slice is allocated. i must be in [0, Len()).
+//
+// For example:
+//
+//	At(1, nil) of `[[0,1], [a,b]]` is [0,b]
+func (p *Product[T]) At(i int, dst []T) []T {
+	if i < 0 || i >= p.count {
+		panic("utils: index out of range of Cartesian product")
+	}
+	if cap(dst) < len(p.lists) {
+		dst = make([]T, len(p.lists))
+	}
+	dst = dst[:len(p.lists)]
+
+	// i is a mixed-radix number whose digits are the indexes in each array
+	for k := len(p.lists) - 1; k >= 0; k-- {
+		n := len(p.lists[k])
+		dst[k] = p.lists[k][i%n]
+		i /= n
+	}
+	return dst
+}
+
+// ForEach calls fn for each tuple of the product in order. The tuple passed to fn is reused
+// for the next tuple, so it must be copied to be retained.
+// If fn returns false, ForEach stops the iteration and returns false.
+func (p *Product[T]) ForEach(fn func(tuple []T) bool) bool {
+	if p.count == 0 {
+		return true
 	}
-	//product := [][]T{{}}
-	for _, next := range a[1:] {
-		nextLen := len(next)
-		newProduct := make([][]T, len(product)*nextLen)
-		for i, c := range product {
-			for j, v := range next {
-				newProduct[i*nextLen+j] = append(c, v)
+
+	indexes := make([]int, len(p.lists))
+	tuple := make([]T, len(p.lists))
+	for k, list := range p.lists {
+		tuple[k] = list[0]
+	}
+
+	for n := 0; n < p.count; n++ {
+		if !fn(tuple) {
+			return false
+		}
+
+		// Increment the mixed-radix number and update the changed values only
+		for k := len(indexes) - 1; k >= 0; k-- {
+			indexes[k]++
+			if indexes[k] < len(p.lists[k]) {
+				tuple[k] = p.lists[k][indexes[k]]
+				break
 			}
+			indexes[k] = 0
+			tuple[k] = p.lists[k][0]
 		}
-		product = newProduct
 	}
-	return product
+	return true
 }
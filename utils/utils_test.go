@@ -55,6 +55,93 @@ func TestCartesianProduct(t *testing.T) {
 	}
 }
 
+// cartesianProduct computes the Cartesian product with nested loops for comparing the results
+func cartesianProduct(a [][]int) [][]int {
+	if len(a) == 0 {
+		return [][]int{}
+	}
+	product := [][]int{{}}
+	for _, list := range a {
+		next := [][]int{}
+		for _, tuple := range product {
+			for _, v := range list {
+				next = append(next, append(append([]int{}, tuple...), v))
+			}
+		}
+		product = next
+	}
+	return product
+}
+
+var CartesianProductAliasingTestcases = [][][]int{
+	{{1, 2}, {3, 4}, {5, 6}},
+	{{1, 2}, {3, 4}, {5, 6}, {7, 8}},
+	{{1, 2, 3}, {4, 5, 6, 7, 8}, {9}, {10, 11}, {12, 13, 14}},
+}
+
+// TestCartesianProductAliasing checks that the tuples of utils.CartesianProduct do not share
+// memory, neither before nor after appending to a tuple.
+func TestCartesianProductAliasing(t *testing.T) {
+	for _, c := range CartesianProductAliasingTestcases {
+		t.Logf("Testcase: %+v\n", c)
+		expected := cartesianProduct(c)
+		product := utils.CartesianProduct(c)
+		if !reflect.DeepEqual(product, expected) {
+			t.Fatalf("Invalid product: actual: %+v expect: %+v", product, expected)
+		}
+
+		for i := range product {
+			product[i] = append(product[i], -1)
+			expected[i] = append(expected[i], -1)
+		}
+		if !reflect.DeepEqual(product, expected) {
+			t.Fatalf("Invalid product after append: actual: %+v expect: %+v", product, expected)
+		}
+	}
+}
+
+// TestProduct tests utils.Product
+func TestProduct(t *testing.T) {
+	for _, c := range append(CartesianProductAliasingTestcases, [][]int{}, [][]int{{1, 2}, {}}) {
+		t.Logf("Testcase: %+v\n", c)
+		expected := cartesianProduct(c)
+		if len(c) > 0 && len(c[len(c)-1]) == 0 {
+			expected = [][]int{}
+		}
+		p := utils.NewProduct(c)
+		if p.Len() != len(expected) {
+			t.Fatalf("Invalid length: actual: %d expect: %d", p.Len(), len(expected))
+		}
+
+		tuples := [][]int{}
+		p.ForEach(func(tuple []int) bool {
+			tuples = append(tuples, append([]int{}, tuple...))
+			return true
+		})
+		if !reflect.DeepEqual(tuples, expected) {
+			t.Fatalf("Invalid tuples: actual: %+v expect: %+v", tuples, expected)
+		}
+
+		buffer := []int{}
+		for i := range expected {
+			buffer = p.At(i, buffer)
+			if !reflect.DeepEqual(buffer, expected[i]) {
+				t.Fatalf("Invalid tuple %d: actual: %+v expect: %+v", i, buffer, expected[i])
+			}
+		}
+	}
+
+	// Stop the iteration early
+	count := 0
+	utils.NewProduct([][]int{{1, 2}, {3, 4}, {5, 6}}).ForEach(func(tuple []int) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatalf("Invalid number of tuples: actual: %d expect: %d", count, 3)
+	}
+}
+
 var CartesianProductBenchmarks = [][][]int{
 	{rand.Perm(10), rand.Perm(10)},
 	{rand.Perm(10), rand.Perm(10), rand.Perm(10)},
@@ -79,3 +166,18 @@ func BenchmarkCartesianProduct(t *testing.B) {
 		})
 	}
 }
+
+func BenchmarkProduct(t *testing.B) {
+	for _, c := range CartesianProductBenchmarks {
+		inputSize := []string{}
+		for _, a := range c {
+			inputSize = append(inputSize, fmt.Sprint(len(a)))
+		}
+		t.Run(strings.Join(inputSize, "x"), func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				utils.NewProduct(c).ForEach(func(tuple []int) bool { return true })
+			}
+		})
+	}
+}